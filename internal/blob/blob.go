@@ -0,0 +1,61 @@
+// Package blob stores file contents, such as todo attachments, outside the
+// database. Blobs are addressed by key and written once; they are never
+// changed in place.
+package blob
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var ErrNotFound = errors.New("blob not found")
+var ErrChecksumMismatch = errors.New("blob checksum mismatch")
+
+// Object describes a blob being written. SHA256 is the hex digest of the
+// content; stores check what they received against it and reject the
+// write with ErrChecksumMismatch when it differs.
+type Object struct {
+	Size        int64
+	ContentType string
+	SHA256      string
+}
+
+// BlobStore keeps blobs by key. Implementations must be safe for
+// concurrent use. Deleting a key that doesn't exist is not an error.
+type BlobStore interface {
+	Put(key string, r io.Reader, obj Object) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// FromEnv picks a store from BLOB_STORE: "local" (the default) keeps blobs
+// in BLOB_DIR on disk, "s3" keeps them in an S3-compatible bucket such as
+// AWS S3 or MinIO, configured by:
+//
+//	S3_ENDPOINT           base URL, e.g. https://s3.eu-west-1.amazonaws.com or http://minio:9000
+//	S3_BUCKET             bucket name
+//	S3_REGION             signing region (default us-east-1)
+//	S3_ACCESS_KEY_ID      access key
+//	S3_SECRET_ACCESS_KEY  secret key
+func FromEnv() (BlobStore, error) {
+	switch kind := os.Getenv("BLOB_STORE"); kind {
+	case "", "local":
+		dir := os.Getenv("BLOB_DIR")
+		if dir == "" {
+			dir = "blobs"
+		}
+		return NewLocalStore(dir)
+	case "s3":
+		return NewS3Store(S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			Region:          os.Getenv("S3_REGION"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown BLOB_STORE %q", kind)
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// assignError maps assignment errors to HTTP responses.
+func assignError(c *gin.Context, err error) {
+	switch err {
+	case models.ErrTodoNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "todo not found"})
+	case models.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case models.ErrInvalidAssignee:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *TodoHandler) AssignTodo(c *gin.Context) {
+	userID, id, ok := shareParams(c)
+	if !ok {
+		return
+	}
+	var req models.AssignTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	todo, err := h.svc.AssignTodoForUser(userID, id, req.AssigneeID)
+	if err != nil {
+		assignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"todo": todo})
+}
+
+func (h *TodoHandler) UnassignTodo(c *gin.Context) {
+	userID, id, ok := shareParams(c)
+	if !ok {
+		return
+	}
+	todo, err := h.svc.UnassignTodoForUser(userID, id)
+	if err != nil {
+		assignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"todo": todo})
+}
+
+// GetAssignedTodos lists the todos assigned to the caller wherever they
+// live, or only those in the workspace named by X-Workspace-ID.
+func (h *TodoHandler) GetAssignedTodos(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	todos, err := h.svc.GetAssignedTodos(userID, workspaceScope(c))
+	if err != nil {
+		assignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"todos": todos})
+}
+
+// GetWorkload counts open and overdue todos per assignee in the caller's
+// personal todos or the current workspace.
+func (h *TodoHandler) GetWorkload(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	workload, err := h.svc.GetWorkload(userID, workspaceScope(c))
+	if err != nil {
+		assignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"workload": workload})
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"mime"
+	"net/http"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AttachmentHandler struct {
+	svc services.AttachmentService
+}
+
+func NewAttachmentHandler(s services.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{svc: s}
+}
+
+// attachmentError maps attachment service errors to HTTP responses.
+func attachmentError(c *gin.Context, err error) {
+	switch err {
+	case models.ErrTodoNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "todo not found"})
+	case models.ErrAttachmentNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case models.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case models.ErrAttachmentTooLarge:
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case models.ErrUnsupportedAttachmentType:
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case models.ErrEmptyAttachment, models.ErrInvalidChecksum, models.ErrChecksumMismatch:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// attachmentParams reads the caller, the todo's :id and the :attachmentId
+// route parameter.
+func attachmentParams(c *gin.Context) (userID uuid.UUID, todoID uuid.UUID, id uuid.UUID, ok bool) {
+	userID, todoID, ok = shareParams(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+	id, err := uuid.Parse(c.Param("attachmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attachment id"})
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+	return userID, todoID, id, true
+}
+
+func (h *AttachmentHandler) GetAttachments(c *gin.Context) {
+	userID, todoID, ok := shareParams(c)
+	if !ok {
+		return
+	}
+	attachments, err := h.svc.GetAttachments(userID, todoID)
+	if err != nil {
+		attachmentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"attachments": attachments})
+}
+
+// UploadAttachment takes a multipart/form-data request with the file in
+// the "file" field. An optional "sha256" field holds the hex digest the
+// file must have.
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	userID, todoID, ok := shareParams(c)
+	if !ok {
+		return
+	}
+	// leave some room for the multipart framing around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.svc.MaxSize()+1<<20)
+	file, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			attachmentError(c, models.ErrAttachmentTooLarge)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a file is required in the \"file\" form field"})
+		return
+	}
+	content, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer content.Close()
+
+	attachment, err := h.svc.UploadAttachment(userID, todoID, &models.AttachmentUpload{
+		Filename: file.Filename,
+		Size:     file.Size,
+		Content:  content,
+		SHA256:   c.PostForm("sha256"),
+	})
+	if err != nil {
+		attachmentError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"attachment": attachment})
+}
+
+// DownloadAttachment streams the file. The Digest header carries its
+// SHA-256 so clients can check what they received.
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	userID, todoID, id, ok := attachmentParams(c)
+	if !ok {
+		return
+	}
+	attachment, content, err := h.svc.OpenAttachment(userID, todoID, id)
+	if err != nil {
+		attachmentError(c, err)
+		return
+	}
+	defer content.Close()
+
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	headers := map[string]string{
+		"Content-Disposition":    disposition,
+		"ETag":                   `"` + attachment.SHA256 + `"`,
+		"X-Content-Type-Options": "nosniff",
+	}
+	if digest, err := hex.DecodeString(attachment.SHA256); err == nil {
+		headers["Digest"] = "sha-256=" + base64.StdEncoding.EncodeToString(digest)
+	}
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, content, headers)
+}
+
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	userID, todoID, id, ok := attachmentParams(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeleteAttachment(userID, todoID, id); err != nil {
+		attachmentError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
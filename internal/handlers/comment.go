@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"net/http"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CommentHandler struct {
+	svc services.CommentService
+}
+
+func NewCommentHandler(s services.CommentService) *CommentHandler {
+	return &CommentHandler{svc: s}
+}
+
+// commentError maps comment service errors to HTTP responses.
+func commentError(c *gin.Context, err error) {
+	switch err {
+	case models.ErrTodoNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "todo not found"})
+	case models.ErrCommentNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case models.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case models.ErrInvalidComment, models.ErrInvalidCursor:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// commentParams reads the caller, the todo's :id and the :commentId route
+// parameter.
+func commentParams(c *gin.Context) (userID uuid.UUID, todoID uuid.UUID, id uuid.UUID, ok bool) {
+	userID, todoID, ok = shareParams(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+	id, err := uuid.Parse(c.Param("commentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid comment id"})
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+	return userID, todoID, id, true
+}
+
+// GetComments lists a todo's comments oldest first. Pass the returned
+// next_cursor as ?cursor= to read on.
+func (h *CommentHandler) GetComments(c *gin.Context) {
+	userID, todoID, ok := shareParams(c)
+	if !ok {
+		return
+	}
+	page, err := parsePageRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	result, err := h.svc.GetComments(userID, todoID, page)
+	if err != nil {
+		commentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *CommentHandler) CreateComment(c *gin.Context) {
+	userID, todoID, ok := shareParams(c)
+	if !ok {
+		return
+	}
+	var req models.CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	comment, err := h.svc.CreateComment(userID, todoID, &req)
+	if err != nil {
+		commentError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"comment": comment})
+}
+
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	userID, todoID, id, ok := commentParams(c)
+	if !ok {
+		return
+	}
+	var req models.UpdateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	comment, err := h.svc.UpdateComment(userID, todoID, id, &req)
+	if err != nil {
+		commentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"comment": comment})
+}
+
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	userID, todoID, id, ok := commentParams(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeleteComment(userID, todoID, id); err != nil {
+		commentError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
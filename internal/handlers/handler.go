@@ -3,8 +3,14 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	models "github.com/danieldzansi/todo-api/internal/model"
 	"github.com/danieldzansi/todo-api/internal/services"
@@ -77,12 +83,164 @@ func (h *TodoHandler) GetAllTodos(c *gin.Context) {
 	}
 	userID, _ := userIDVal.(uuid.UUID)
 
-	todos, err := h.svc.GetAllTodosByUser(userID)
+	filter, err := parseTodoFilter(c)
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.WorkspaceID = workspaceScope(c)
+	page, err := parsePageRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	result, err := h.svc.ListTodosByUser(userID, filter, page)
+	if err != nil {
+		if err == models.ErrInvalidCursor {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"todos": todos})
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *TodoHandler) SearchTodos(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+
+	limit := 0
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		limit = n
+	}
+	results, err := h.svc.SearchTodosByUser(userID, workspaceScope(c), c.Query("q"), limit)
+	if err != nil {
+		if err == models.ErrInvalidSearchQuery {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// parseTodoFilter reads the filtering and sorting query parameters.
+func parseTodoFilter(c *gin.Context) (models.TodoFilter, error) {
+	var filter models.TodoFilter
+	var err error
+	if v := c.Query("completed"); v != "" {
+		completed, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, errors.New("invalid completed value")
+		}
+		filter.Completed = &completed
+	}
+	if v := c.Query("priority"); v != "" {
+		priority, err := models.ParsePriority(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.Priority = &priority
+	}
+	if v := c.Query("overdue"); v != "" {
+		if filter.Overdue, err = strconv.ParseBool(v); err != nil {
+			return filter, errors.New("invalid overdue value")
+		}
+	}
+	if filter.DueBefore, err = parseTimeQuery(c, "due_before"); err != nil {
+		return filter, err
+	}
+	if filter.DueAfter, err = parseTimeQuery(c, "due_after"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedSince, err = parseTimeQuery(c, "created_since"); err != nil {
+		return filter, err
+	}
+	switch v := c.Query("project_id"); v {
+	case "":
+	case "inbox":
+		filter.Inbox = true
+	default:
+		projectID, err := uuid.Parse(v)
+		if err != nil {
+			return filter, errors.New("invalid project_id value")
+		}
+		filter.ProjectID = &projectID
+	}
+	switch v := c.Query("assignee_id"); v {
+	case "":
+	case "none":
+		filter.Unassigned = true
+	default:
+		assigneeID, err := uuid.Parse(v)
+		if err != nil {
+			return filter, errors.New("invalid assignee_id value")
+		}
+		filter.AssigneeID = &assigneeID
+	}
+	if v := c.Query("include_subtasks"); v != "" {
+		if filter.IncludeSubtasks, err = strconv.ParseBool(v); err != nil {
+			return filter, errors.New("invalid include_subtasks value")
+		}
+	}
+	if v := c.Query("tags"); v != "" {
+		filter.Tags = strings.Split(v, ",")
+	}
+	switch match := models.TagMatch(c.DefaultQuery("tag_match", string(models.TagMatchAny))); match {
+	case models.TagMatchAny, models.TagMatchAll:
+		filter.TagMatch = match
+	default:
+		return filter, errors.New("tag_match must be any or all")
+	}
+	if filter.Sort, err = models.ParseTodoSort(c.Query("sort")); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// parseTimeQuery accepts either an RFC 3339 timestamp or a plain date.
+func parseTimeQuery(c *gin.Context, name string) (*time.Time, error) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid %s value", name)
+}
+
+// parsePageRequest reads the limit and cursor query parameters.
+func parsePageRequest(c *gin.Context) (models.PageRequest, error) {
+	var page models.PageRequest
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return page, errors.New("invalid limit")
+		}
+		page.Limit = limit
+	}
+	if v := c.Query("cursor"); v != "" {
+		cursor, err := models.DecodeCursor(v)
+		if err != nil {
+			return page, err
+		}
+		page.Cursor = cursor
+	}
+	return page, nil
 }
 
 func (h *TodoHandler) GetTodoByID(c *gin.Context) {
@@ -148,8 +306,24 @@ func (h *TodoHandler) CreateTodo(c *gin.Context) {
 		return
 	}
 	userID, _ := userIDVal.(uuid.UUID)
-	todo, err := h.svc.CreateTodoForUser(userID, &req)
+	todo, err := h.svc.CreateTodoForUser(userID, workspaceScope(c), &req)
 	if err != nil {
+		if err == models.ErrProjectNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+			return
+		}
+		if err == models.ErrWrongWorkspace || err == models.ErrInvalidAssignee {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err == models.ErrForbidden {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, models.ErrInvalidRecurrence) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -196,6 +370,22 @@ func (h *TodoHandler) UpdateTodo(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "todo not found"})
 			return
 		}
+		if err == models.ErrForbidden {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err == models.ErrProjectNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+			return
+		}
+		if err == models.ErrWrongWorkspace || err == models.ErrInvalidAssignee || err == models.ErrSubtaskProject {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, models.ErrInvalidRecurrence) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -231,6 +421,10 @@ func (h *TodoHandler) DeleteTodo(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "todo not found"})
 			return
 		}
+		if err == models.ErrForbidden {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -256,18 +450,247 @@ func (h *TodoHandler) ToggleTodoComplete(c *gin.Context) {
 		return
 	}
 	userID, _ := userIDVal.(uuid.UUID)
-	todo, err := h.svc.ToggleTodoCompleteForUser(userID, id)
+	// ?cascade=true also completes the todo's subtasks
+	cascade, _ := strconv.ParseBool(c.Query("cascade"))
+	todo, err := h.svc.ToggleTodoCompleteForUser(userID, id, cascade)
+	if err != nil {
+		if err == models.ErrTodoNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "todo not found"})
+			return
+		}
+		if err == models.ErrForbidden {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"todo": todo})
+}
+
+// MoveTodo files a todo under another project, or back in the inbox when
+// project_id is null.
+func (h *TodoHandler) MoveTodo(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req models.MoveTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	todo, err := h.svc.MoveTodoForUser(userID, id, req.ProjectID)
 	if err != nil {
 		if err == models.ErrTodoNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "todo not found"})
 			return
 		}
+		if err == models.ErrForbidden {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err == models.ErrProjectNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+			return
+		}
+		if err == models.ErrWrongWorkspace || err == models.ErrSubtaskProject {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"todo": todo})
 }
 
+func (h *TodoHandler) GetSubtasks(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	subtasks, err := h.svc.GetSubtasksForUser(userID, id)
+	if err != nil {
+		if err == models.ErrTodoNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "todo not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"subtasks": subtasks})
+}
+
+func (h *TodoHandler) CreateSubtask(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req models.CreateTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	todo, err := h.svc.CreateSubtaskForUser(userID, id, &req)
+	if err != nil {
+		if err == models.ErrTodoNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "todo not found"})
+			return
+		}
+		if err == models.ErrForbidden {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err == models.ErrInvalidParent || err == models.ErrInvalidAssignee {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"todo": todo})
+}
+
+func (h *TodoHandler) ReorderSubtasks(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req models.ReorderSubtasksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	subtasks, err := h.svc.ReorderSubtasksForUser(userID, id, req.IDs)
+	if err != nil {
+		if err == models.ErrTodoNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "todo not found"})
+			return
+		}
+		if err == models.ErrForbidden {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err == models.ErrInvalidSubtaskOrder {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"subtasks": subtasks})
+}
+
+func (h *TodoHandler) GetTrash(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	todos, err := h.svc.GetTrashByUser(userID, workspaceScope(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"todos": todos})
+}
+
+func (h *TodoHandler) EmptyTrash(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	purged, err := h.svc.EmptyTrashForUser(userID, workspaceScope(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"purged": purged})
+}
+
+func (h *TodoHandler) RestoreTodo(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	todo, err := h.svc.RestoreTodoForUser(userID, workspaceScope(c), id)
+	if err != nil {
+		if err == models.ErrTodoNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "todo not found in trash"})
+			return
+		}
+		if err == models.ErrParentInTrash {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"todo": todo})
+}
+
+// PurgeTodo permanently deletes a todo that is already in the trash.
+func (h *TodoHandler) PurgeTodo(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	if err := h.svc.PurgeTodoForUser(userID, workspaceScope(c), id); err != nil {
+		if err == models.ErrTodoNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "todo not found in trash"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
 // User Handler Methods
 func (h *UserHandler) Signup(c *gin.Context) {
 	var req models.SignupRequest
@@ -311,14 +734,172 @@ func (h *UserHandler) Login(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
 		return
 	}
+	req.ClientIP = c.ClientIP()
 	resp, err := h.authSvc.Login(&req)
 	if err != nil {
+		loginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": resp})
+}
+
+// loginError maps login failures to responses. Unexpected errors are not
+// echoed back, so nothing about the account leaks through them.
+func loginError(c *gin.Context, err error) {
+	var throttled *models.LoginThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"success": false, "error": err.Error()})
+	case err == models.ErrEmailNotVerified:
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "error": err.Error()})
+	case err == models.ErrInvalidCredentials, err == models.ErrUserNotFound:
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "invalid email or password"})
+	case err == models.ErrInvalidUserToken, err == models.ErrInvalidTOTPCode, err == models.ErrTOTPNotEnabled:
 		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "login failed"})
+	}
+}
+
+func (h *UserHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	resp, err := h.authSvc.Refresh(&req)
+	if err != nil {
+		if err == models.ErrInvalidRefreshToken || err == models.ErrRefreshTokenReused || err == models.ErrUserNotFound {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": resp})
 }
 
+func (h *UserHandler) Logout(c *gin.Context) {
+	claimsVal, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized"})
+		return
+	}
+	claims, _ := claimsVal.(*models.AccessClaims)
+
+	var req models.LogoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+	}
+	if err := h.authSvc.Logout(claims, &req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Logged out"})
+}
+
+func (h *UserHandler) LogoutAll(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+
+	if err := h.authSvc.LogoutAll(userID); err != nil {
+		if err == models.ErrUserNotFound {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Logged out of all sessions"})
+}
+
+func (h *UserHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if err := h.authSvc.ForgotPassword(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	// same answer whether or not the account exists
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "If an account with that email exists, a reset token has been sent",
+	})
+}
+
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if err := h.authSvc.ResetPassword(&req); err != nil {
+		if err == models.ErrInvalidUserToken || err == models.ErrUserNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": models.ErrInvalidUserToken.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Password has been reset"})
+}
+
+// VerifyEmail accepts the token either as ?token= (the link in the mail) or
+// as a JSON body.
+func (h *UserHandler) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if c.Request.Method == http.MethodGet {
+		req.Token = c.Query("token")
+		if req.Token == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "token is required"})
+			return
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if err := h.authSvc.VerifyEmail(&req); err != nil {
+		if err == models.ErrInvalidUserToken || err == models.ErrUserNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": models.ErrInvalidUserToken.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Email verified"})
+}
+
+func (h *UserHandler) ResendVerification(c *gin.Context) {
+	var req models.ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if err := h.authSvc.ResendVerification(&req); err != nil {
+		if err == models.ErrVerificationThrottled {
+			c.JSON(http.StatusTooManyRequests, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "If the account exists and is unverified, a verification email has been sent",
+	})
+}
+
 func (h *UserHandler) GetUserByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
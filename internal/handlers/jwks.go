@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/danieldzansi/todo-api/internal/signing"
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS serves the public keys other services can verify our access tokens
+// with.
+func JWKS(keys *signing.KeySet) gin.HandlerFunc {
+	set := keys.JWKS()
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, set)
+	}
+}
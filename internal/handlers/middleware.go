@@ -2,15 +2,15 @@ package handlers
 
 import (
 	"net/http"
-	"os"
 	"strings"
 
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/services"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(authSvc services.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
@@ -18,32 +18,157 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			secret = "dev-secret-change-me"
+		claims, err := authSvc.Authenticate(tokenString)
+		if err != nil {
+			if err == models.ErrInvalidToken || err == models.ErrTokenRevoked {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to verify token"})
+			return
+		}
+		c.Set("userID", claims.UserID)
+		c.Set("claims", claims)
+		c.Next()
+	}
+}
+
+// RequireVerifiedEmail rejects requests that change data from users who
+// haven't verified their email yet. Reads are let through. It must run
+// after AuthMiddleware.
+func RequireVerifiedEmail(authSvc services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+		if claims := accessClaims(c); claims != nil {
+			if claims.EmailVerified {
+				c.Next()
+				return
+			}
+			verified, err := authSvc.IsEmailVerified(claims.UserID)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check email verification"})
+				return
+			}
+			if verified {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": models.ErrEmailNotVerified.Error()})
+	}
+}
+
+// accessClaims returns the claims AuthMiddleware stored on the context.
+func accessClaims(c *gin.Context) *models.AccessClaims {
+	claimsVal, _ := c.Get("claims")
+	claims, _ := claimsVal.(*models.AccessClaims)
+	return claims
+}
+
+// RequireRole lets the request through if the token carries any of roles.
+// It must run after AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if claims := accessClaims(c); claims != nil {
+			for _, role := range roles {
+				if claims.HasRole(role) {
+					c.Next()
+					return
+				}
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+	}
+}
+
+// RequirePermission lets the request through if the token grants all of
+// perms. It must run after AuthMiddleware.
+func RequirePermission(perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := accessClaims(c)
+		if claims == nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
 		}
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrInvalidKeyType
+		for _, perm := range perms {
+			if !claims.HasPermission(perm) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing permission " + perm})
+				return
 			}
-			return []byte(secret), nil
-		})
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		}
+		c.Next()
+	}
+}
+
+// RequireReadWrite checks read on GET and HEAD requests and write on
+// everything else, for route groups that mix both.
+func RequireReadWrite(read, write string) gin.HandlerFunc {
+	readOnly, readWrite := RequirePermission(read), RequirePermission(write)
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			readOnly(c)
+			return
+		}
+		readWrite(c)
+	}
+}
+
+// RequireSession rejects personal access tokens, for endpoints such as
+// token management that a leaked script token must not reach. It must run
+// after AuthMiddleware.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if claims := accessClaims(c); claims == nil || claims.PersonalToken {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": models.ErrSessionRequired.Error()})
 			return
 		}
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+		c.Next()
+	}
+}
+
+// WorkspaceContext scopes the request to the workspace named in the
+// X-Workspace-ID header, after checking the caller is a member of it.
+// Without the header requests work on the caller's personal todos. It must
+// run after AuthMiddleware.
+func WorkspaceContext(workspaceSvc services.WorkspaceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("X-Workspace-ID")
+		if header == "" {
+			c.Next()
 			return
 		}
-		sub, _ := claims["sub"].(string)
-		userID, err := uuid.Parse(sub)
+		workspaceID, err := uuid.Parse(header)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid subject in token"})
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid X-Workspace-ID header"})
 			return
 		}
-		c.Set("userID", userID)
+		userIDVal, _ := c.Get("userID")
+		userID, _ := userIDVal.(uuid.UUID)
+		role, err := workspaceSvc.Membership(userID, workspaceID)
+		if err != nil {
+			if err == models.ErrWorkspaceNotFound {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check workspace membership"})
+			return
+		}
+		c.Set("workspaceID", workspaceID)
+		c.Set("workspaceRole", role)
 		c.Next()
 	}
 }
+
+// workspaceScope returns the workspace WorkspaceContext put the request
+// in, or nil for the caller's personal space.
+func workspaceScope(c *gin.Context) *uuid.UUID {
+	workspaceIDVal, exists := c.Get("workspaceID")
+	if !exists {
+		return nil
+	}
+	workspaceID, _ := workspaceIDVal.(uuid.UUID)
+	return &workspaceID
+}
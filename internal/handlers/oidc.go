@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCHandler struct {
+	svc services.OIDCService
+}
+
+func NewOIDCHandler(s services.OIDCService) *OIDCHandler {
+	return &OIDCHandler{svc: s}
+}
+
+func (h *OIDCHandler) GetProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.svc.Providers()})
+}
+
+// Login redirects the browser to the identity provider.
+func (h *OIDCHandler) Login(c *gin.Context) {
+	authURL, err := h.svc.StartLogin(c.Param("provider"))
+	if err != nil {
+		if err == models.ErrOIDCProviderNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+		log.Printf("OIDC login start failed: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"success": false, "error": "identity provider unavailable"})
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback is where the identity provider sends the browser back to. It
+// answers with the same body as POST /users/login.
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	if e := c.Query("error"); e != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": e, "error_description": c.Query("error_description")})
+		return
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "code and state are required"})
+		return
+	}
+	resp, err := h.svc.FinishLogin(c.Param("provider"), code, state)
+	if err != nil {
+		switch err {
+		case models.ErrOIDCProviderNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": err.Error()})
+		case models.ErrInvalidOIDCState, models.ErrOIDCEmailNotVerified:
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": err.Error()})
+		case models.ErrOIDCAccountNotVerified:
+			c.JSON(http.StatusConflict, gin.H{"success": false, "error": err.Error()})
+		default:
+			log.Printf("OIDC login failed: %v", err)
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "login with identity provider failed"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": resp})
+}
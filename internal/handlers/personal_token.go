@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// personalTokenError maps personal access token errors to HTTP responses.
+func personalTokenError(c *gin.Context, err error) {
+	switch err {
+	case models.ErrPersonalTokenNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case models.ErrPersonalTokenExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case models.ErrInvalidScope, models.ErrInvalidPersonalTokenExpiry:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *UserHandler) GetPersonalTokens(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	tokens, err := h.authSvc.GetPersonalTokens(userID)
+	if err != nil {
+		personalTokenError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens})
+}
+
+func (h *UserHandler) CreatePersonalToken(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	var req models.CreatePersonalTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	token, err := h.authSvc.CreatePersonalToken(userID, &req)
+	if err != nil {
+		personalTokenError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, token)
+}
+
+func (h *UserHandler) RevokePersonalToken(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid token id"})
+		return
+	}
+	if err := h.authSvc.RevokePersonalToken(userID, id); err != nil {
+		personalTokenError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "token revoked"})
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"net/http"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// profileError maps profile errors to HTTP responses.
+func profileError(c *gin.Context, err error) {
+	switch err {
+	case models.ErrInvalidName, models.ErrSameEmail, models.ErrInvalidDeleteMode:
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+	case models.ErrInvalidUserToken:
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+	case models.ErrInvalidCredentials:
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "current password is incorrect"})
+	case models.ErrInvalidTOTPCode:
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": err.Error()})
+	case models.ErrUserAlreadyExists:
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": "User with this email already exists"})
+	case models.ErrLastAdmin, models.ErrLastOwner:
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": err.Error()})
+	case models.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "User not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+	}
+}
+
+// GetMe returns the caller's own profile.
+func (h *UserHandler) GetMe(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	user, err := h.authSvc.GetUserByID(userID)
+	if err != nil {
+		profileError(c, err)
+		return
+	}
+	user.Password = ""
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": user})
+}
+
+func (h *UserHandler) UpdateMe(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	var req models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	user, err := h.authSvc.UpdateProfile(userID, &req)
+	if err != nil {
+		profileError(c, err)
+		return
+	}
+	user.Password = ""
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": user})
+}
+
+// ChangePassword answers with new tokens, since every existing session,
+// including the caller's, is logged out.
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	resp, err := h.authSvc.ChangePassword(userID, &req)
+	if err != nil {
+		profileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Password changed", "data": resp})
+}
+
+func (h *UserHandler) ChangeEmail(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	var req models.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if err := h.authSvc.RequestEmailChange(userID, &req); err != nil {
+		profileError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "A confirmation link has been sent to the new address",
+	})
+}
+
+// ConfirmEmailChange accepts the token either as ?token= (the link in the
+// mail) or as a JSON body.
+func (h *UserHandler) ConfirmEmailChange(c *gin.Context) {
+	var req models.ConfirmEmailChangeRequest
+	if c.Request.Method == http.MethodGet {
+		req.Token = c.Query("token")
+		if req.Token == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "token is required"})
+			return
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if err := h.authSvc.ConfirmEmailChange(&req); err != nil {
+		if err == models.ErrUserNotFound {
+			err = models.ErrInvalidUserToken
+		}
+		profileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Email changed"})
+}
+
+func (h *UserHandler) DeleteMe(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if err := h.authSvc.DeleteAccount(userID, &req); err != nil {
+		profileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Account deleted"})
+}
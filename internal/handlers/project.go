@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ProjectHandler struct {
+	svc services.ProjectService
+}
+
+func NewProjectHandler(s services.ProjectService) *ProjectHandler {
+	return &ProjectHandler{svc: s}
+}
+
+// projectError maps project service errors to HTTP responses.
+func projectError(c *gin.Context, err error) {
+	switch err {
+	case models.ErrProjectNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+	case models.ErrInvalidDeletePolicy:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case models.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *ProjectHandler) GetAllProjects(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	includeArchived, _ := strconv.ParseBool(c.Query("include_archived"))
+	projects, err := h.svc.GetProjectsByUser(userID, workspaceScope(c), includeArchived)
+	if err != nil {
+		projectError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"projects": projects})
+}
+
+func (h *ProjectHandler) GetProjectByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	project, err := h.svc.GetProjectByIDForUser(userID, id)
+	if err != nil {
+		projectError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"project": project})
+}
+
+func (h *ProjectHandler) CreateProject(c *gin.Context) {
+	var req models.CreateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	project, err := h.svc.CreateProjectForUser(userID, workspaceScope(c), &req)
+	if err != nil {
+		projectError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"project": project})
+}
+
+func (h *ProjectHandler) UpdateProject(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req models.UpdateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	project, err := h.svc.UpdateProjectForUser(userID, id, &req)
+	if err != nil {
+		projectError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"project": project})
+}
+
+// DeleteProject deletes a project. ?policy=cascade also deletes its todos;
+// the default, ?policy=inbox, moves them to the inbox.
+func (h *ProjectHandler) DeleteProject(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	policy := models.ProjectDeletePolicy(c.Query("policy"))
+	if err := h.svc.DeleteProjectForUser(userID, id, policy); err != nil {
+		projectError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RoleHandler struct {
+	svc services.RoleService
+}
+
+func NewRoleHandler(s services.RoleService) *RoleHandler {
+	return &RoleHandler{svc: s}
+}
+
+// roleError maps role service errors to HTTP responses.
+func roleError(c *gin.Context, err error) {
+	switch err {
+	case models.ErrRoleNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+	case models.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+	case models.ErrRoleAlreadyExists, models.ErrBuiltinRole, models.ErrLastAdmin:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case models.ErrInvalidRoleName, models.ErrInvalidPermission:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *RoleHandler) GetAllRoles(c *gin.Context) {
+	roles, err := h.svc.GetRoles()
+	if err != nil {
+		roleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": roles, "permissions": models.KnownPermissions})
+}
+
+func (h *RoleHandler) GetRole(c *gin.Context) {
+	role, err := h.svc.GetRole(c.Param("name"))
+	if err != nil {
+		roleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, role)
+}
+
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var req models.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	role, err := h.svc.CreateRole(&req)
+	if err != nil {
+		roleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, role)
+}
+
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	var req models.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	role, err := h.svc.UpdateRole(c.Param("name"), &req)
+	if err != nil {
+		roleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, role)
+}
+
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	if err := h.svc.DeleteRole(c.Param("name")); err != nil {
+		roleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "role deleted"})
+}
+
+func (h *RoleHandler) GetUserRoles(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	roles, err := h.svc.GetUserRoles(userID)
+	if err != nil {
+		roleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+func (h *RoleHandler) SetUserRoles(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	var req models.SetUserRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	roles, err := h.svc.SetUserRoles(userID, &req)
+	if err != nil {
+		roleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
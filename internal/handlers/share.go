@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"net/http"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ShareHandler struct {
+	svc services.ShareService
+}
+
+func NewShareHandler(s services.ShareService) *ShareHandler {
+	return &ShareHandler{svc: s}
+}
+
+// shareError maps sharing errors to HTTP responses.
+func shareError(c *gin.Context, err error) {
+	switch err {
+	case models.ErrTodoNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "todo not found"})
+	case models.ErrProjectNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+	case models.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "no user with that email"})
+	case models.ErrShareNotFound, models.ErrInvitationNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case models.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case models.ErrInvalidShareRole, models.ErrShareWithSelf, models.ErrShareWorkspaceItem:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// shareParams reads the caller and the :id route parameter.
+func shareParams(c *gin.Context) (userID uuid.UUID, id uuid.UUID, ok bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	userID, _ = userIDVal.(uuid.UUID)
+	return userID, id, true
+}
+
+func (h *ShareHandler) ShareTodo(c *gin.Context) {
+	userID, id, ok := shareParams(c)
+	if !ok {
+		return
+	}
+	var req models.CreateShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	share, err := h.svc.ShareTodo(userID, id, &req)
+	if err != nil {
+		shareError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"share": share})
+}
+
+func (h *ShareHandler) GetTodoShares(c *gin.Context) {
+	userID, id, ok := shareParams(c)
+	if !ok {
+		return
+	}
+	shares, err := h.svc.GetTodoShares(userID, id)
+	if err != nil {
+		shareError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"shares": shares})
+}
+
+func (h *ShareHandler) UnshareTodo(c *gin.Context) {
+	userID, id, ok := shareParams(c)
+	if !ok {
+		return
+	}
+	shareUserID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	if err := h.svc.UnshareTodo(userID, id, shareUserID); err != nil {
+		shareError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ShareHandler) ShareProject(c *gin.Context) {
+	userID, id, ok := shareParams(c)
+	if !ok {
+		return
+	}
+	var req models.CreateShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	share, err := h.svc.ShareProject(userID, id, &req)
+	if err != nil {
+		shareError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"share": share})
+}
+
+func (h *ShareHandler) GetProjectShares(c *gin.Context) {
+	userID, id, ok := shareParams(c)
+	if !ok {
+		return
+	}
+	shares, err := h.svc.GetProjectShares(userID, id)
+	if err != nil {
+		shareError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"shares": shares})
+}
+
+func (h *ShareHandler) UnshareProject(c *gin.Context) {
+	userID, id, ok := shareParams(c)
+	if !ok {
+		return
+	}
+	shareUserID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	if err := h.svc.UnshareProject(userID, id, shareUserID); err != nil {
+		shareError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetSharedTodos lists todos shared with the caller, optionally only those
+// in ?project_id=.
+func (h *ShareHandler) GetSharedTodos(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	var projectID *uuid.UUID
+	if v := c.Query("project_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project_id"})
+			return
+		}
+		projectID = &id
+	}
+	todos, err := h.svc.GetSharedTodos(userID, projectID)
+	if err != nil {
+		shareError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"todos": todos})
+}
+
+func (h *ShareHandler) GetSharedProjects(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	projects, err := h.svc.GetSharedProjects(userID)
+	if err != nil {
+		shareError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"projects": projects})
+}
+
+func (h *ShareHandler) GetInvitations(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	invitations, err := h.svc.GetInvitations(userID)
+	if err != nil {
+		shareError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+func (h *ShareHandler) AcceptInvitation(c *gin.Context) {
+	userID, id, ok := shareParams(c)
+	if !ok {
+		return
+	}
+	share, err := h.svc.AcceptInvitation(userID, id)
+	if err != nil {
+		shareError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"share": share})
+}
+
+func (h *ShareHandler) DeclineInvitation(c *gin.Context) {
+	userID, id, ok := shareParams(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeclineInvitation(userID, id); err != nil {
+		shareError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
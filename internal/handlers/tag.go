@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TagHandler struct {
+	svc services.TagService
+}
+
+func NewTagHandler(s services.TagService) *TagHandler {
+	return &TagHandler{svc: s}
+}
+
+// tagError maps tag service errors to HTTP responses.
+func tagError(c *gin.Context, err error) {
+	switch err {
+	case models.ErrTagNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+	case models.ErrTagAlreadyExists:
+		c.JSON(http.StatusConflict, gin.H{"error": "tag with this name already exists"})
+	case models.ErrInvalidTagName:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *TagHandler) GetAllTags(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	tags, err := h.svc.GetTagsByUser(userID)
+	if err != nil {
+		tagError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+func (h *TagHandler) GetTagByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	tag, err := h.svc.GetTagByIDForUser(userID, id)
+	if err != nil {
+		tagError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tag": tag})
+}
+
+func (h *TagHandler) CreateTag(c *gin.Context) {
+	var req models.CreateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	tag, err := h.svc.CreateTagForUser(userID, &req)
+	if err != nil {
+		tagError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"tag": tag})
+}
+
+func (h *TagHandler) UpdateTag(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req models.UpdateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	tag, err := h.svc.UpdateTagForUser(userID, id, &req)
+	if err != nil {
+		tagError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tag": tag})
+}
+
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	if err := h.svc.DeleteTagForUser(userID, id); err != nil {
+		tagError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// totpError maps two-factor errors to HTTP responses.
+func totpError(c *gin.Context, err error) {
+	switch err {
+	case models.ErrInvalidTOTPCode, models.ErrInvalidCredentials:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case models.ErrTOTPAlreadyEnabled, models.ErrTOTPNotEnabled, models.ErrTOTPNotEnrolled:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case models.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *UserHandler) EnrollTOTP(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	enrollment, err := h.authSvc.EnrollTOTP(userID)
+	if err != nil {
+		totpError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *UserHandler) ConfirmTOTP(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	var req models.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	codes, err := h.authSvc.ConfirmTOTP(userID, &req)
+	if err != nil {
+		totpError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, codes)
+}
+
+func (h *UserHandler) DisableTOTP(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	var req models.DisableTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.authSvc.DisableTOTP(userID, &req); err != nil {
+		totpError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+func (h *UserHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	var req models.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	codes, err := h.authSvc.RegenerateRecoveryCodes(userID, &req)
+	if err != nil {
+		totpError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, codes)
+}
+
+// VerifyLogin completes a login that answered with two_factor_required.
+func (h *UserHandler) VerifyLogin(c *gin.Context) {
+	var req models.VerifyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	req.ClientIP = c.ClientIP()
+	resp, err := h.authSvc.VerifyLogin(&req)
+	if err != nil {
+		loginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": resp})
+}
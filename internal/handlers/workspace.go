@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"net/http"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type WorkspaceHandler struct {
+	svc services.WorkspaceService
+}
+
+func NewWorkspaceHandler(s services.WorkspaceService) *WorkspaceHandler {
+	return &WorkspaceHandler{svc: s}
+}
+
+// workspaceError maps workspace service errors to HTTP responses.
+func workspaceError(c *gin.Context, err error) {
+	switch err {
+	case models.ErrWorkspaceNotFound, models.ErrMemberNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case models.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "no user with that email"})
+	case models.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case models.ErrAlreadyMember, models.ErrLastOwner:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case models.ErrInvalidWorkspaceName, models.ErrInvalidWorkspaceRole:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// memberParams reads the caller, the :id route parameter and the :userId
+// route parameter.
+func memberParams(c *gin.Context) (userID uuid.UUID, id uuid.UUID, memberID uuid.UUID, ok bool) {
+	userID, id, ok = shareParams(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+	memberID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+	return userID, id, memberID, true
+}
+
+func (h *WorkspaceHandler) GetWorkspaces(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	workspaces, err := h.svc.GetWorkspaces(userID)
+	if err != nil {
+		workspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"workspaces": workspaces})
+}
+
+func (h *WorkspaceHandler) CreateWorkspace(c *gin.Context) {
+	var req models.CreateWorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := userIDVal.(uuid.UUID)
+	workspace, err := h.svc.CreateWorkspace(userID, &req)
+	if err != nil {
+		workspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"workspace": workspace})
+}
+
+func (h *WorkspaceHandler) GetWorkspace(c *gin.Context) {
+	userID, id, ok := shareParams(c)
+	if !ok {
+		return
+	}
+	workspace, err := h.svc.GetWorkspace(userID, id)
+	if err != nil {
+		workspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"workspace": workspace})
+}
+
+func (h *WorkspaceHandler) UpdateWorkspace(c *gin.Context) {
+	userID, id, ok := shareParams(c)
+	if !ok {
+		return
+	}
+	var req models.UpdateWorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	workspace, err := h.svc.UpdateWorkspace(userID, id, &req)
+	if err != nil {
+		workspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"workspace": workspace})
+}
+
+// DeleteWorkspace deletes the workspace together with its todos and
+// projects.
+func (h *WorkspaceHandler) DeleteWorkspace(c *gin.Context) {
+	userID, id, ok := shareParams(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeleteWorkspace(userID, id); err != nil {
+		workspaceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *WorkspaceHandler) GetMembers(c *gin.Context) {
+	userID, id, ok := shareParams(c)
+	if !ok {
+		return
+	}
+	members, err := h.svc.GetMembers(userID, id)
+	if err != nil {
+		workspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+func (h *WorkspaceHandler) AddMember(c *gin.Context) {
+	userID, id, ok := shareParams(c)
+	if !ok {
+		return
+	}
+	var req models.AddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	member, err := h.svc.AddMember(userID, id, &req)
+	if err != nil {
+		workspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"member": member})
+}
+
+func (h *WorkspaceHandler) UpdateMember(c *gin.Context) {
+	userID, id, memberID, ok := memberParams(c)
+	if !ok {
+		return
+	}
+	var req models.UpdateMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	member, err := h.svc.UpdateMember(userID, id, memberID, &req)
+	if err != nil {
+		workspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"member": member})
+}
+
+// RemoveMember takes a member out of the workspace; members can remove
+// themselves to leave.
+func (h *WorkspaceHandler) RemoveMember(c *gin.Context) {
+	userID, id, memberID, ok := memberParams(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.RemoveMember(userID, id, memberID); err != nil {
+		workspaceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
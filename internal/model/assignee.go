@@ -0,0 +1,23 @@
+package models
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidAssignee = errors.New("todos can only be assigned to someone who can see them")
+
+type AssignTodoRequest struct {
+	AssigneeID uuid.UUID `json:"assignee_id" binding:"required"`
+}
+
+// Workload counts the open todos assigned to one person. The entry without
+// an AssigneeID counts the unassigned ones.
+type Workload struct {
+	AssigneeID *uuid.UUID `json:"assignee_id"`
+	Name       string     `json:"name,omitempty"`
+	Email      string     `json:"email,omitempty"`
+	Open       int        `json:"open"`
+	Overdue    int        `json:"overdue"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultMaxAttachmentSize is the upload limit unless ATTACHMENT_MAX_BYTES
+// says otherwise.
+const DefaultMaxAttachmentSize = 10 << 20
+
+// DefaultAttachmentTypes are the media types accepted unless
+// ATTACHMENT_TYPES says otherwise: screenshots, PDFs and plain text.
+var DefaultAttachmentTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain"}
+
+var ErrAttachmentNotFound = errors.New("attachment not found")
+var ErrAttachmentTooLarge = errors.New("file is larger than the attachment size limit")
+var ErrEmptyAttachment = errors.New("file is empty")
+var ErrUnsupportedAttachmentType = errors.New("file type is not allowed as an attachment")
+var ErrInvalidChecksum = errors.New("sha256 must be 64 hex characters")
+var ErrChecksumMismatch = errors.New("file does not match its sha256 checksum")
+
+// Attachment is a file attached to a todo. ContentType is sniffed from the
+// content rather than taken from the client, and SHA256 is the hex digest
+// of the content.
+type Attachment struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	TodoID      uuid.UUID  `json:"todo_id" db:"todo_id"`
+	UploadedBy  *uuid.UUID `json:"uploaded_by" db:"uploaded_by"`
+	Filename    string     `json:"filename" db:"filename"`
+	ContentType string     `json:"content_type" db:"content_type"`
+	Size        int64      `json:"size" db:"size"`
+	SHA256      string     `json:"sha256" db:"sha256"`
+	StorageKey  string     `json:"-" db:"storage_key"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// AttachmentUpload is a file received for attaching. SHA256, when the
+// client sends it, is the digest the content must have.
+type AttachmentUpload struct {
+	Filename string
+	Size     int64
+	Content  io.ReadSeeker
+	SHA256   string
+}
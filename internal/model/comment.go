@@ -0,0 +1,51 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const MaxCommentLength = 10000
+
+var ErrCommentNotFound = errors.New("comment not found")
+var ErrInvalidComment = errors.New("comment must be between 1 and 10000 characters")
+
+// Comment is a note on a todo's discussion thread. EditedAt is set once the
+// author changes the body.
+type Comment struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	TodoID    uuid.UUID  `json:"todo_id" db:"todo_id"`
+	AuthorID  *uuid.UUID `json:"author_id" db:"author_id"`
+	Body      string     `json:"body" db:"body"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty" db:"edited_at"`
+
+	// filled in when reading, for display
+	AuthorName string    `json:"author_name" db:"-"`
+	Mentions   []Mention `json:"mentions" db:"-"`
+}
+
+// Mention is a user an @email in a comment resolved to.
+type Mention struct {
+	UserID uuid.UUID `json:"user_id"`
+	Name   string    `json:"name"`
+	Email  string    `json:"email"`
+}
+
+type CreateCommentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+type UpdateCommentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// CommentPage is a page of a thread, oldest comments first.
+type CommentPage struct {
+	Comments   []Comment `json:"comments"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	HasMore    bool      `json:"has_more"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidSort = errors.New("invalid sort")
+
+// TodoSort names an ordering for todo listings. A leading "-" means
+// descending.
+type TodoSort string
+
+const (
+	SortCreatedAtDesc TodoSort = "-created_at"
+	SortCreatedAt     TodoSort = "created_at"
+	SortDueDate       TodoSort = "due_date"
+	SortDueDateDesc   TodoSort = "-due_date"
+	SortTitle         TodoSort = "title"
+	SortTitleDesc     TodoSort = "-title"
+	// priority sorts break ties by due date, soonest first
+	SortPriorityDesc TodoSort = "-priority"
+	SortPriority     TodoSort = "priority"
+)
+
+func ParseTodoSort(s string) (TodoSort, error) {
+	switch sort := TodoSort(s); sort {
+	case "":
+		return SortCreatedAtDesc, nil
+	case SortCreatedAtDesc, SortCreatedAt, SortDueDate, SortDueDateDesc, SortTitle, SortTitleDesc,
+		SortPriorityDesc, SortPriority:
+		return sort, nil
+	}
+	return "", ErrInvalidSort
+}
+
+// TodoFilter narrows a todo listing. Nil and zero fields are not applied.
+type TodoFilter struct {
+	// WorkspaceID lists the workspace's todos instead of the user's
+	// personal ones.
+	WorkspaceID  *uuid.UUID
+	Completed    *bool
+	Priority     *Priority
+	DueBefore    *time.Time
+	DueAfter     *time.Time
+	Overdue      bool
+	CreatedSince *time.Time
+	ProjectID    *uuid.UUID
+	Inbox        bool
+	AssigneeID   *uuid.UUID
+	Unassigned   bool
+	// IncludeSubtasks lists subtasks alongside top-level todos.
+	IncludeSubtasks bool
+	Tags            []string
+	TagMatch        TagMatch
+	Sort            TodoSort
+}
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+var ErrInvalidSearchQuery = errors.New("search query must contain at least one word")
+
+// TodoSearchResult is a todo matched by full-text search. The highlight
+// fields are HTML: the todo's text, escaped, with matched words wrapped in
+// <mark> tags.
+type TodoSearchResult struct {
+	Todo           Todo    `json:"todo"`
+	Rank           float64 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reasons recorded for failed login attempts.
+const (
+	LoginFailUnknownEmail = "unknown_email"
+	LoginFailBadPassword  = "bad_password"
+	LoginFailBadCode      = "bad_code"
+	LoginFailLocked       = "locked"
+)
+
+// LoginAttempt is one audited login attempt. Email is stored lowercased
+// whether or not an account exists for it.
+type LoginAttempt struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	Email     string     `json:"email" db:"email"`
+	UserID    *uuid.UUID `json:"user_id,omitempty" db:"user_id"`
+	IP        string     `json:"ip" db:"ip"`
+	Success   bool       `json:"success" db:"success"`
+	Reason    string     `json:"reason,omitempty" db:"reason"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// LoginThrottledError is returned while an account or client IP has to
+// wait before trying to log in again.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts; try again in %s", e.RetryAfter.Round(time.Second))
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrOIDCProviderNotFound = errors.New("unknown identity provider")
+var ErrInvalidOIDCState = errors.New("login session expired or invalid; start again")
+var ErrOIDCEmailNotVerified = errors.New("the identity provider has not verified this email address")
+var ErrOIDCAccountNotVerified = errors.New("an account with this email exists but has not verified it; sign in with your password and verify your email before using the identity provider")
+
+// OIDCState is a login started with an identity provider and not finished
+// yet.
+type OIDCState struct {
+	StateHash    string    `db:"state_hash"`
+	Provider     string    `db:"provider"`
+	CodeVerifier string    `db:"code_verifier"`
+	Nonce        string    `db:"nonce"`
+	ExpiresAt    time.Time `db:"expires_at"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+// UserIdentity links a user to their account at an identity provider.
+type UserIdentity struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Provider  string    `json:"provider" db:"provider"`
+	Issuer    string    `json:"issuer" db:"issuer"`
+	Subject   string    `json:"subject" db:"subject"`
+	Email     string    `json:"email,omitempty" db:"email"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
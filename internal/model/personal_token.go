@@ -0,0 +1,44 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrPersonalTokenNotFound = errors.New("personal access token not found")
+var ErrPersonalTokenExists = errors.New("a personal access token with this name already exists")
+var ErrInvalidScope = errors.New("scopes must be permissions you hold")
+var ErrInvalidPersonalTokenExpiry = errors.New("expires_at must be in the future")
+var ErrSessionRequired = errors.New("this endpoint needs a login session, not a personal access token")
+
+// PersonalTokenPrefix starts every personal access token, which is how
+// AuthMiddleware tells them apart from JWTs.
+const PersonalTokenPrefix = "tdp_"
+
+type PersonalAccessToken struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	TokenHash  string     `json:"-" db:"token_hash"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+type CreatePersonalTokenRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreatedPersonalToken is returned once, on creation; Token can't be
+// retrieved again.
+type CreatedPersonalToken struct {
+	PersonalAccessToken
+	Token string `json:"token"`
+}
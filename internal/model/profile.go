@@ -0,0 +1,47 @@
+package models
+
+import "errors"
+
+var ErrInvalidName = errors.New("name must not be empty")
+var ErrSameEmail = errors.New("that is already your email address")
+var ErrInvalidDeleteMode = errors.New(`todos must be "delete" or "anonymize"`)
+
+// UpdateProfileRequest changes the caller's own profile. Email changes go
+// through ChangeEmailRequest so the new address gets verified first.
+type UpdateProfileRequest struct {
+	Name *string `json:"name,omitempty"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ChangeEmailRequest starts an email change. The address is only switched
+// once the link mailed to NewEmail is followed.
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// DeleteMode says what happens to a deleted account's todos.
+type DeleteMode string
+
+const (
+	// DeleteTodos removes the account along with everything it owns.
+	DeleteTodos DeleteMode = "delete"
+	// AnonymizeTodos scrubs the account's personal data but keeps its todos,
+	// owned by a tombstone user that can no longer log in.
+	AnonymizeTodos DeleteMode = "anonymize"
+)
+
+type DeleteAccountRequest struct {
+	Password string     `json:"password" binding:"required"`
+	Todos    DeleteMode `json:"todos"`
+	// Code is needed when two-factor authentication is on
+	Code string `json:"code"`
+}
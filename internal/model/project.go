@@ -0,0 +1,55 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrProjectNotFound = errors.New("project not found")
+var ErrInvalidDeletePolicy = errors.New("delete policy must be inbox or cascade")
+
+type Project struct {
+	ID     uuid.UUID `json:"id" db:"id"`
+	UserID uuid.UUID `json:"user_id" db:"user_id"`
+	// WorkspaceID is set for projects that belong to a workspace
+	WorkspaceID *uuid.UUID `json:"workspace_id,omitempty" db:"workspace_id"`
+	Name        string     `json:"name" db:"name"`
+	Color       string     `json:"color,omitempty" db:"color"`
+	Archived    bool       `json:"archived" db:"archived"`
+	SortOrder   int        `json:"sort_order" db:"sort_order"`
+	TodoCount   int        `json:"todo_count" db:"-"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type CreateProjectRequest struct {
+	Name      string `json:"name" binding:"required"`
+	Color     string `json:"color"`
+	SortOrder int    `json:"sort_order"`
+}
+
+type UpdateProjectRequest struct {
+	Name      *string `json:"name,omitempty"`
+	Color     *string `json:"color,omitempty"`
+	Archived  *bool   `json:"archived,omitempty"`
+	SortOrder *int    `json:"sort_order,omitempty"`
+}
+
+// MoveTodoRequest moves a todo into a project, or back to the inbox when
+// ProjectID is null.
+type MoveTodoRequest struct {
+	ProjectID *uuid.UUID `json:"project_id"`
+}
+
+// ProjectDeletePolicy decides what happens to a project's todos when the
+// project is deleted.
+type ProjectDeletePolicy string
+
+const (
+	// DeleteMoveToInbox keeps the todos and clears their project.
+	DeleteMoveToInbox ProjectDeletePolicy = "inbox"
+	// DeleteCascade deletes the todos along with the project.
+	DeleteCascade ProjectDeletePolicy = "cascade"
+)
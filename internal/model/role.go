@@ -0,0 +1,84 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrRoleNotFound = errors.New("role not found")
+var ErrRoleAlreadyExists = errors.New("role already exists")
+var ErrInvalidRoleName = errors.New("role name must be 1-32 lowercase letters, digits, '-' or '_', starting with a letter")
+var ErrInvalidPermission = errors.New("unknown permission")
+var ErrBuiltinRole = errors.New("built-in roles cannot be changed or deleted")
+var ErrLastAdmin = errors.New("at least one user must keep the admin role")
+
+// Built-in roles. Every new account gets RoleUser.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Permissions checked by RequirePermission. The todos permissions also
+// cover tags and projects.
+const (
+	PermTodosRead   = "todos:read"
+	PermTodosWrite  = "todos:write"
+	PermUsersRead   = "users:read"
+	PermRolesManage = "roles:manage"
+)
+
+var KnownPermissions = []string{PermTodosRead, PermTodosWrite, PermUsersRead, PermRolesManage}
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`)
+
+func ValidateRoleName(name string) error {
+	if !roleNamePattern.MatchString(name) {
+		return ErrInvalidRoleName
+	}
+	return nil
+}
+
+func ValidatePermissions(perms []string) error {
+	for _, p := range perms {
+		known := false
+		for _, k := range KnownPermissions {
+			if p == k {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return ErrInvalidPermission
+		}
+	}
+	return nil
+}
+
+type Role struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description,omitempty" db:"description"`
+	Permissions []string  `json:"permissions" db:"permissions"`
+	Builtin     bool      `json:"builtin" db:"builtin"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRoleRequest struct {
+	Description *string   `json:"description,omitempty"`
+	Permissions *[]string `json:"permissions,omitempty"`
+}
+
+// SetUserRolesRequest replaces all of a user's roles.
+type SetUserRolesRequest struct {
+	Roles []string `json:"roles" binding:"required"`
+}
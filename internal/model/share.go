@@ -0,0 +1,80 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrForbidden = errors.New("you don't have permission to do that")
+var ErrShareNotFound = errors.New("share not found")
+var ErrInvitationNotFound = errors.New("invitation not found")
+var ErrInvalidShareRole = errors.New(`role must be "viewer" or "editor"`)
+var ErrShareWithSelf = errors.New("you can't share with yourself")
+
+// ShareRole is what a user may do with a todo or project. Roles are
+// ordered: an editor can do everything a viewer can, and an owner
+// everything an editor can.
+type ShareRole string
+
+const (
+	ShareViewer ShareRole = "viewer"
+	ShareEditor ShareRole = "editor"
+	ShareOwner  ShareRole = "owner"
+)
+
+var shareRoleRank = map[ShareRole]int{ShareViewer: 1, ShareEditor: 2, ShareOwner: 3}
+
+// Allows reports whether r is at least need.
+func (r ShareRole) Allows(need ShareRole) bool {
+	return shareRoleRank[r] >= shareRoleRank[need]
+}
+
+// ShareStatus tracks an invitation. Declined invitations are deleted.
+type ShareStatus string
+
+const (
+	SharePending  ShareStatus = "pending"
+	ShareAccepted ShareStatus = "accepted"
+)
+
+// Share grants UserID a role on either a todo (with its subtasks) or a
+// whole project. It only takes effect once the invitation is accepted.
+type Share struct {
+	ID          uuid.UUID   `json:"id" db:"id"`
+	TodoID      *uuid.UUID  `json:"todo_id,omitempty" db:"todo_id"`
+	ProjectID   *uuid.UUID  `json:"project_id,omitempty" db:"project_id"`
+	UserID      uuid.UUID   `json:"user_id" db:"user_id"`
+	Role        ShareRole   `json:"role" db:"role"`
+	Status      ShareStatus `json:"status" db:"status"`
+	InvitedBy   *uuid.UUID  `json:"invited_by,omitempty" db:"invited_by"`
+	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
+	RespondedAt *time.Time  `json:"responded_at,omitempty" db:"responded_at"`
+
+	// filled in when listing, for display
+	UserName      string `json:"user_name,omitempty" db:"-"`
+	UserEmail     string `json:"user_email,omitempty" db:"-"`
+	Title         string `json:"title,omitempty" db:"-"`
+	InvitedByName string `json:"invited_by_name,omitempty" db:"-"`
+}
+
+// CreateShareRequest invites the user with Email. Sharing again with the
+// same user changes their role.
+type CreateShareRequest struct {
+	Email string    `json:"email" binding:"required"`
+	Role  ShareRole `json:"role" binding:"required"`
+}
+
+// SharedTodo is a todo someone else owns, with the caller's role on it.
+type SharedTodo struct {
+	Todo
+	Role ShareRole `json:"role"`
+}
+
+// SharedProject is a project someone else owns, with the caller's role on
+// it.
+type SharedProject struct {
+	Project
+	Role ShareRole `json:"role"`
+}
@@ -2,34 +2,80 @@ package models
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 var ErrTodoNotFound = errors.New("todo not found")
+var ErrInvalidParent = errors.New("subtasks cannot have subtasks of their own")
+var ErrSubtaskProject = errors.New("subtasks stay in their parent's project; move the parent instead")
+var ErrInvalidSubtaskOrder = errors.New("order must list every subtask exactly once")
+var ErrInvalidRecurrence = errors.New("invalid recurrence rule")
+var ErrParentInTrash = errors.New("restore the parent todo first")
 
 type Todo struct {
 	ID          uuid.UUID  `json:"id" db:"id"`
 	Title       string     `json:"title" db:"title" binding:"required"`
 	Description string     `json:"description" db:"description"`
 	Completed   bool       `json:"completed" db:"completed"`
+	Priority    Priority   `json:"priority" db:"priority"`
 	DueDate     *time.Time `json:"due_date,omitempty" db:"due_date"`
 	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
+	ProjectID   *uuid.UUID `json:"project_id" db:"project_id"`
+	WorkspaceID *uuid.UUID `json:"workspace_id,omitempty" db:"workspace_id"`
+	AssigneeID  *uuid.UUID `json:"assignee_id,omitempty" db:"assignee_id"`
+	ParentID    *uuid.UUID `json:"parent_id,omitempty" db:"parent_id"`
+	Position    int        `json:"position" db:"position"`
+	Recurrence  *string    `json:"recurrence,omitempty" db:"recurrence"`
+	Tags        []string   `json:"tags" db:"-"`
+	Progress    *Progress  `json:"progress,omitempty" db:"-"`
 	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+
+	// NextOccurrence is set when completing a recurring todo spawned its
+	// successor.
+	NextOccurrence *Todo `json:"next_occurrence,omitempty" db:"-"`
 }
 
 type CreateTodoRequest struct {
 	Title       string     `json:"title" binding:"required"`
 	Description string     `json:"description"`
+	Priority    Priority   `json:"priority"`
 	DueDate     *time.Time `json:"due_date,omitempty"`
+	ProjectID   *uuid.UUID `json:"project_id,omitempty"`
+	AssigneeID  *uuid.UUID `json:"assignee_id,omitempty"`
+	Recurrence  *string    `json:"recurrence,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
 }
 
 type UpdateTodoRequest struct {
 	Title       *string    `json:"title,omitempty"`
 	Description *string    `json:"description,omitempty"`
+	Priority    *Priority  `json:"priority,omitempty"`
 	DueDate     *time.Time `json:"due_date,omitempty"`
+	ProjectID   *uuid.UUID `json:"project_id,omitempty"`
+	AssigneeID  *uuid.UUID `json:"assignee_id,omitempty"` // unassign with DELETE /todos/:id/assignee
+	Recurrence  *string    `json:"recurrence,omitempty"`  // "" stops the todo repeating
+	Tags        *[]string  `json:"tags,omitempty"`
+}
+
+// Progress summarizes a todo's subtasks.
+type Progress struct {
+	Done  int    `json:"done"`
+	Total int    `json:"total"`
+	Label string `json:"label"`
+}
+
+func NewProgress(done, total int) *Progress {
+	return &Progress{Done: done, Total: total, Label: fmt.Sprintf("%d/%d done", done, total)}
+}
+
+// ReorderSubtasksRequest lists a todo's subtask ids in their new order.
+type ReorderSubtasksRequest struct {
+	IDs []uuid.UUID `json:"ids" binding:"required"`
 }
 
 type TodoResponse struct {
@@ -46,6 +92,10 @@ type User struct {
 	Todos     []Todo    `json:"todos,omitempty" db:"-"` // db:"-" so it won't try to store as column
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" db:"email_verified_at"`
+	TOTPEnabledAt   *time.Time `json:"totp_enabled_at,omitempty" db:"totp_enabled_at"`
+	Roles           []string   `json:"roles,omitempty" db:"-"`
 }
 
 type CreateUser struct {
@@ -69,13 +119,25 @@ type UserResponse struct {
 
 var ErrUserNotFound = errors.New("user not found")
 var ErrUserAlreadyExists = errors.New("user already exists")
+var ErrEmailNotVerified = errors.New("email address has not been verified")
 
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	// ClientIP is filled in by the handler for throttling
+	ClientIP string `json:"-"`
 }
 
+// LoginResponse carries either the tokens or, for accounts with two-factor
+// authentication, a challenge to complete with POST /users/login/verify.
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token            string     `json:"token,omitempty"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	RefreshToken     string     `json:"refresh_token,omitempty"`
+	RefreshExpiresAt *time.Time `json:"refresh_expires_at,omitempty"`
+	User             *User      `json:"user,omitempty"`
+
+	TwoFactorRequired  bool       `json:"two_factor_required,omitempty"`
+	ChallengeToken     string     `json:"challenge_token,omitempty"`
+	ChallengeExpiresAt *time.Time `json:"challenge_expires_at,omitempty"`
 }
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCredentials = errors.New("invalid credentials")
+var ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+var ErrRefreshTokenReused = errors.New("refresh token reuse detected; all sessions from this login were revoked")
+var ErrInvalidToken = errors.New("invalid token")
+var ErrTokenRevoked = errors.New("token has been revoked")
+var ErrInvalidUserToken = errors.New("invalid or expired token")
+var ErrVerificationThrottled = errors.New("a verification email was sent recently; try again later")
+
+// RefreshToken is the stored form of a refresh token; only the hash of the
+// token itself is kept.
+type RefreshToken struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	FamilyID   uuid.UUID  `json:"family_id" db:"family_id"`
+	TokenHash  string     `json:"-" db:"token_hash"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	ReplacedBy *uuid.UUID `json:"replaced_by,omitempty" db:"replaced_by"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	// RefreshToken, when given, is revoked along with the rest of its family.
+	RefreshToken string `json:"refresh_token"`
+}
+
+// AccessClaims are the claims of a verified access token.
+// EmailVerified, Roles and Permissions are as of when the token was issued.
+// For personal access tokens, TokenID is the token's id and Permissions are
+// its scopes narrowed to what the user's roles still grant.
+type AccessClaims struct {
+	UserID        uuid.UUID
+	Email         string
+	EmailVerified bool
+	Roles         []string
+	Permissions   []string
+	TokenID       uuid.UUID
+	IssuedAt      time.Time
+	ExpiresAt     time.Time
+	PersonalToken bool
+}
+
+// TokenPurpose says what a UserToken may be used for.
+type TokenPurpose string
+
+const (
+	PurposePasswordReset     TokenPurpose = "password_reset"
+	PurposeEmailVerification TokenPurpose = "email_verification"
+	PurposeLoginChallenge    TokenPurpose = "login_challenge"
+	PurposeEmailChange       TokenPurpose = "email_change"
+)
+
+// UserToken is a single-use token mailed to a user; only its hash is stored.
+type UserToken struct {
+	ID        uuid.UUID    `json:"id" db:"id"`
+	UserID    uuid.UUID    `json:"user_id" db:"user_id"`
+	Purpose   TokenPurpose `json:"purpose" db:"purpose"`
+	TokenHash string       `json:"-" db:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time   `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+	// Email is the new address an email change token confirms
+	Email *string `json:"email,omitempty" db:"email"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+func (c *AccessClaims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *AccessClaims) HasPermission(perm string) bool {
+	for _, p := range c.Permissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
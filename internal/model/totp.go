@@ -0,0 +1,47 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+var ErrTOTPNotEnabled = errors.New("two-factor authentication is not enabled")
+var ErrTOTPNotEnrolled = errors.New("start enrollment before confirming a code")
+var ErrInvalidTOTPCode = errors.New("invalid two-factor code")
+
+// TOTPState is a user's two-factor setup. Secret is set once enrollment has
+// started; EnabledAt once it was confirmed.
+type TOTPState struct {
+	Secret    *string
+	EnabledAt *time.Time
+	LastStep  *int64
+}
+
+type TOTPEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableTOTPRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// RecoveryCodes are shown once; each can stand in for a TOTP code one time.
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// VerifyLoginRequest completes a login that returned a challenge. Code is
+// either a TOTP code or a recovery code.
+type VerifyLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+	// ClientIP is filled in by the handler for throttling
+	ClientIP string `json:"-"`
+}
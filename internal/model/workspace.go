@@ -0,0 +1,77 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrWorkspaceNotFound = errors.New("workspace not found")
+var ErrInvalidWorkspaceName = errors.New("workspace name must not be empty")
+var ErrInvalidWorkspaceRole = errors.New(`role must be "owner", "admin" or "member"`)
+var ErrAlreadyMember = errors.New("user is already a member of this workspace")
+var ErrMemberNotFound = errors.New("member not found")
+var ErrLastOwner = errors.New("a workspace must keep at least one owner")
+var ErrWrongWorkspace = errors.New("project belongs to a different workspace")
+var ErrShareWorkspaceItem = errors.New("workspace todos and projects are shared by adding members to the workspace")
+
+// WorkspaceRole is a member's role in a workspace. Admins manage members;
+// only owners can hand out ownership or delete the workspace.
+type WorkspaceRole string
+
+const (
+	WorkspaceMember WorkspaceRole = "member"
+	WorkspaceAdmin  WorkspaceRole = "admin"
+	WorkspaceOwner  WorkspaceRole = "owner"
+)
+
+var workspaceRoleRank = map[WorkspaceRole]int{WorkspaceMember: 1, WorkspaceAdmin: 2, WorkspaceOwner: 3}
+
+// Allows reports whether r is at least need.
+func (r WorkspaceRole) Allows(need WorkspaceRole) bool {
+	return workspaceRoleRank[r] >= workspaceRoleRank[need]
+}
+
+func (r WorkspaceRole) Valid() bool {
+	_, ok := workspaceRoleRank[r]
+	return ok
+}
+
+type Workspace struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	Name        string     `json:"name" db:"name"`
+	CreatedBy   *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	MemberCount int        `json:"member_count" db:"-"`
+	// Role is the caller's role in the workspace
+	Role WorkspaceRole `json:"role,omitempty" db:"-"`
+}
+
+type WorkspaceMembership struct {
+	WorkspaceID uuid.UUID     `json:"workspace_id" db:"workspace_id"`
+	UserID      uuid.UUID     `json:"user_id" db:"user_id"`
+	Role        WorkspaceRole `json:"role" db:"role"`
+	CreatedAt   time.Time     `json:"created_at" db:"created_at"`
+	Name        string        `json:"name" db:"-"`
+	Email       string        `json:"email" db:"-"`
+}
+
+type CreateWorkspaceRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type UpdateWorkspaceRequest struct {
+	Name *string `json:"name,omitempty"`
+}
+
+// AddMemberRequest adds an existing user to a workspace by email.
+type AddMemberRequest struct {
+	Email string        `json:"email" binding:"required"`
+	Role  WorkspaceRole `json:"role"`
+}
+
+type UpdateMemberRequest struct {
+	Role WorkspaceRole `json:"role" binding:"required"`
+}
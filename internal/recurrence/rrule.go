@@ -0,0 +1,269 @@
+// Package recurrence implements the subset of RFC 5545 recurrence rules used
+// for repeating todos: FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL,
+// BYDAY (weekly rules only, without ordinals), BYMONTHDAY (monthly rules
+// only), COUNT and UNTIL.
+package recurrence
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxSteps bounds the search for the next occurrence so a rule that can
+// never match (e.g. BYMONTHDAY=31 every 2 months from February) terminates.
+const maxSteps = 1000
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay []int
+	// Count is the number of occurrences left, including the current one;
+	// zero means unlimited.
+	Count int
+	Until *time.Time
+}
+
+// Parse reads a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH". A leading
+// "RRULE:" is accepted.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, fmt.Errorf("empty rule")
+	}
+	r := &Rule{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || value == "" {
+			return nil, fmt.Errorf("malformed rule part %q", part)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate %s", key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "FREQ":
+			switch f := Frequency(value); f {
+			case Daily, Weekly, Monthly, Yearly:
+				r.Freq = f
+			default:
+				return nil, fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL %q", value)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid COUNT %q", value)
+			}
+			r.Count = n
+		case "UNTIL":
+			t, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			r.Until = &t
+		case "BYDAY":
+			for _, d := range strings.Split(value, ",") {
+				wd, ok := weekdays[d]
+				if !ok {
+					return nil, fmt.Errorf("unsupported BYDAY value %q", d)
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			for _, d := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(d)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("invalid BYMONTHDAY value %q", d)
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported rule part %s", key)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, fmt.Errorf("FREQ is required")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return nil, fmt.Errorf("COUNT and UNTIL cannot both be set")
+	}
+	if len(r.ByDay) > 0 && r.Freq != Weekly {
+		return nil, fmt.Errorf("BYDAY is only supported with FREQ=WEEKLY")
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq != Monthly {
+		return nil, fmt.Errorf("BYMONTHDAY is only supported with FREQ=MONTHLY")
+	}
+	return r, nil
+}
+
+func parseUntil(v string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q", v)
+}
+
+// String returns the rule in canonical RRULE form, without the "RRULE:"
+// prefix.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			days[i] = strings.ToUpper(wd.String()[:2])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			days[i] = strconv.Itoa(d)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence strictly after from, keeping from's
+// time of day and location. It reports false once the series is over.
+func (r *Rule) Next(from time.Time) (time.Time, bool) {
+	if r.Count == 1 {
+		return time.Time{}, false
+	}
+	next, ok := r.next(from)
+	if !ok || (r.Until != nil && next.After(*r.Until)) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// Advance returns the rule that governs the series after one occurrence is
+// used up.
+func (r *Rule) Advance() *Rule {
+	next := *r
+	if next.Count > 1 {
+		next.Count--
+	}
+	return &next
+}
+
+func (r *Rule) next(from time.Time) (time.Time, bool) {
+	switch r.Freq {
+	case Daily:
+		return from.AddDate(0, 0, r.Interval), true
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			return from.AddDate(0, 0, 7*r.Interval), true
+		}
+		start := weekStart(from)
+		for i := 1; i <= 7*r.Interval+7; i++ {
+			d := from.AddDate(0, 0, i)
+			weeks := int(weekStart(d).Sub(start).Hours()+12) / (24 * 7)
+			if weeks%r.Interval == 0 && r.hasDay(d.Weekday()) {
+				return d, true
+			}
+		}
+	case Monthly:
+		for k := 0; k < maxSteps; k++ {
+			first := time.Date(from.Year(), from.Month()+time.Month(k*r.Interval), 1,
+				from.Hour(), from.Minute(), from.Second(), from.Nanosecond(), from.Location())
+			for _, day := range r.monthDays(first, from.Day()) {
+				if d := first.AddDate(0, 0, day-1); d.After(from) {
+					return d, true
+				}
+			}
+		}
+	case Yearly:
+		for k := 1; k < maxSteps; k++ {
+			d := time.Date(from.Year()+k*r.Interval, from.Month(), from.Day(),
+				from.Hour(), from.Minute(), from.Second(), from.Nanosecond(), from.Location())
+			// skip years where the date does not exist, e.g. February 29
+			if d.Day() == from.Day() {
+				return d, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func (r *Rule) hasDay(wd time.Weekday) bool {
+	for _, d := range r.ByDay {
+		if d == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// monthDays resolves the rule's days of month for the month starting at
+// first, sorted and limited to days that exist. Without BYMONTHDAY the
+// series keeps the day of month it started on.
+func (r *Rule) monthDays(first time.Time, startDay int) []int {
+	last := first.AddDate(0, 1, -1).Day()
+	want := r.ByMonthDay
+	if len(want) == 0 {
+		want = []int{startDay}
+	}
+	var days []int
+	for _, d := range want {
+		if d < 0 {
+			d = last + d + 1
+		}
+		if d >= 1 && d <= last {
+			days = append(days, d)
+		}
+	}
+	sort.Ints(days)
+	return days
+}
+
+// weekStart returns midnight on the Monday of t's week.
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	y, m, d := t.AddDate(0, 0, -offset).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
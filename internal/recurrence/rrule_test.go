@@ -0,0 +1,182 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d, hour int) time.Time {
+	return time.Date(y, m, d, hour, 0, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"RRULE:freq=weekly;interval=2;byday=mo,th", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH"},
+		{" FREQ=WEEKLY ; INTERVAL=1 ", "FREQ=WEEKLY"},
+		{"FREQ=MONTHLY;BYMONTHDAY=1,-1;COUNT=5", "FREQ=MONTHLY;BYMONTHDAY=1,-1;COUNT=5"},
+		{"FREQ=YEARLY;UNTIL=20250131", "FREQ=YEARLY;UNTIL=20250131T000000Z"},
+		{"FREQ=DAILY;UNTIL=20250131T235959Z", "FREQ=DAILY;UNTIL=20250131T235959Z"},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if got := r.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"RRULE:",
+		"INTERVAL=2",
+		"FREQ",
+		"FREQ=",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;INTERVAL=x",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=DAILY;COUNT=2;UNTIL=20250101",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYMONTHDAY=-32",
+		"FREQ=DAILY;BYSETPOS=1",
+	} {
+		if r, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) = %q, want an error", in, r)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		rule string
+		from time.Time
+		want time.Time // zero when the series is over
+	}{
+		{"FREQ=DAILY", date(2025, 1, 31, 9), date(2025, 2, 1, 9)},
+		{"FREQ=DAILY;INTERVAL=3", date(2025, 1, 30, 9), date(2025, 2, 2, 9)},
+		{"FREQ=WEEKLY", date(2025, 1, 1, 9), date(2025, 1, 8, 9)},
+		{"FREQ=WEEKLY;INTERVAL=2", date(2025, 12, 24, 9), date(2026, 1, 7, 9)},
+
+		// BYDAY: Jan 6 2025 is a Monday
+		{"FREQ=WEEKLY;BYDAY=MO,TH", date(2025, 1, 6, 9), date(2025, 1, 9, 9)},
+		{"FREQ=WEEKLY;BYDAY=MO,TH", date(2025, 1, 9, 9), date(2025, 1, 13, 9)},
+		{"FREQ=WEEKLY;BYDAY=MO,TH", date(2025, 1, 11, 9), date(2025, 1, 13, 9)},
+		{"FREQ=WEEKLY;BYDAY=SU", date(2025, 1, 6, 9), date(2025, 1, 12, 9)},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", date(2025, 1, 6, 9), date(2025, 1, 9, 9)},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", date(2025, 1, 9, 9), date(2025, 1, 20, 9)},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", date(2025, 1, 6, 9), date(2025, 1, 20, 9)},
+
+		// month ends
+		{"FREQ=MONTHLY", date(2025, 1, 15, 9), date(2025, 2, 15, 9)},
+		{"FREQ=MONTHLY", date(2025, 1, 31, 9), date(2025, 3, 31, 9)},
+		{"FREQ=MONTHLY;INTERVAL=3", date(2025, 11, 30, 9), date(2026, 5, 30, 9)},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", date(2025, 1, 31, 9), date(2025, 2, 28, 9)},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", date(2024, 1, 31, 9), date(2024, 2, 29, 9)},
+		{"FREQ=MONTHLY;BYMONTHDAY=31", date(2025, 3, 31, 9), date(2025, 5, 31, 9)},
+		{"FREQ=MONTHLY;BYMONTHDAY=1,15", date(2025, 1, 10, 9), date(2025, 1, 15, 9)},
+		{"FREQ=MONTHLY;BYMONTHDAY=15,1", date(2025, 1, 15, 9), date(2025, 2, 1, 9)},
+		{"FREQ=MONTHLY;INTERVAL=12;BYMONTHDAY=30", date(2025, 2, 28, 9), time.Time{}},
+
+		{"FREQ=YEARLY", date(2025, 3, 1, 9), date(2026, 3, 1, 9)},
+		{"FREQ=YEARLY", date(2024, 2, 29, 9), date(2028, 2, 29, 9)},
+		{"FREQ=YEARLY;INTERVAL=3", date(2024, 2, 29, 9), date(2036, 2, 29, 9)},
+
+		// UNTIL is inclusive; COUNT includes the current occurrence
+		{"FREQ=DAILY;UNTIL=20250105", date(2025, 1, 4, 0), date(2025, 1, 5, 0)},
+		{"FREQ=DAILY;UNTIL=20250105", date(2025, 1, 4, 9), time.Time{}},
+		{"FREQ=WEEKLY;BYDAY=MO,FR;UNTIL=20250110T000000Z", date(2025, 1, 6, 9), time.Time{}},
+		{"FREQ=DAILY;COUNT=2", date(2025, 1, 4, 9), date(2025, 1, 5, 9)},
+		{"FREQ=DAILY;COUNT=1", date(2025, 1, 4, 9), time.Time{}},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.rule, err)
+		}
+		got, ok := r.Next(tt.from)
+		if ok != !tt.want.IsZero() || !got.Equal(tt.want) {
+			t.Errorf("%s: Next(%s) = %s, %v; want %s", tt.rule, tt.from.Format(time.RFC3339), got.Format(time.RFC3339), ok, tt.want.Format(time.RFC3339))
+		}
+	}
+}
+
+func TestNextKeepsLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	r, err := Parse("FREQ=WEEKLY;BYDAY=TU")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 23:30 on Monday Jan 6 is already Tuesday in UTC; weekdays follow from's zone
+	from := time.Date(2025, 1, 6, 23, 30, 0, 0, loc)
+	want := time.Date(2025, 1, 7, 23, 30, 0, 0, loc)
+	if got, ok := r.Next(from); !ok || !got.Equal(want) || got.Location() != loc {
+		t.Errorf("Next(%s) = %s, %v; want %s", from, got, ok, want)
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"FREQ=DAILY;COUNT=3", "FREQ=DAILY;COUNT=2"},
+		{"FREQ=DAILY;COUNT=2", "FREQ=DAILY;COUNT=1"},
+		{"FREQ=DAILY;COUNT=1", "FREQ=DAILY;COUNT=1"},
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"FREQ=DAILY;UNTIL=20250105", "FREQ=DAILY;UNTIL=20250105T000000Z"},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.rule, err)
+		}
+		before := r.String()
+		if got := r.Advance().String(); got != tt.want {
+			t.Errorf("Parse(%q).Advance() = %q, want %q", tt.rule, got, tt.want)
+		}
+		if r.String() != before {
+			t.Errorf("Advance changed %q to %q", before, r.String())
+		}
+	}
+}
+
+// TestCountSeries walks a series the way completed todos do, advancing once
+// per occurrence, and checks it yields exactly COUNT dates.
+func TestCountSeries(t *testing.T) {
+	r, err := Parse("FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Time{date(2025, 1, 6, 9), date(2025, 1, 8, 9), date(2025, 1, 13, 9), date(2025, 1, 15, 9)}
+	got := []time.Time{want[0]}
+	for d, ok := r.Next(want[0]); ok; d, ok = r.Next(d) {
+		got = append(got, d)
+		r = r.Advance()
+		if len(got) > len(want) {
+			break
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("series has %d occurrences, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("occurrence %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
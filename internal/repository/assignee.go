@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"fmt"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/google/uuid"
+)
+
+// AssignTodo sets or, with a nil assigneeID, clears who the user's todo is
+// assigned to.
+func (r *todoRepository) AssignTodo(userID uuid.UUID, id uuid.UUID, assigneeID *uuid.UUID) (*models.Todo, error) {
+	res, err := r.db.Exec(`
+	  UPDATE todos SET assignee_id = $1, updated_at = now()
+	  WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL
+	`, assigneeID, id, userID)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, models.ErrInvalidAssignee
+		}
+		return nil, fmt.Errorf("failed to assign todo: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return nil, models.ErrTodoNotFound
+	}
+	return r.GetTodoByIDForUser(userID, id)
+}
+
+// GetAssignedTodos lists the todos assigned to the user, open ones first
+// and soonest due first, optionally only those in workspaceID. Only todos
+// the user can still see are listed, so one drops out once they leave its
+// workspace or its share is revoked.
+func (r *todoRepository) GetAssignedTodos(userID uuid.UUID, workspaceID *uuid.UUID) ([]models.Todo, error) {
+	todos, err := r.queryTodos(`
+	  SELECT `+todoColumns+`
+	  FROM todos t
+	  WHERE t.assignee_id = $1 AND t.deleted_at IS NULL
+	    AND ($2::uuid IS NULL OR t.workspace_id = $2)
+	    AND CASE WHEN t.workspace_id IS NOT NULL THEN EXISTS (
+	      SELECT 1 FROM workspace_members m WHERE m.workspace_id = t.workspace_id AND m.user_id = $1
+	    ) ELSE t.user_id = $1 OR EXISTS (
+	      SELECT 1 FROM todo_shares s
+	      WHERE s.user_id = $1 AND s.status = 'accepted'
+	        AND (s.todo_id IN (t.id, t.parent_id) OR s.project_id = t.project_id)
+	    ) END
+	  ORDER BY t.completed, COALESCE(t.due_date, 'infinity'::timestamptz), t.created_at DESC, t.id DESC
+	`, userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if todos == nil {
+		todos = []models.Todo{}
+	}
+	return todos, nil
+}
+
+// GetWorkload counts open and overdue todos per assignee across the user's
+// personal todos, or across all of workspaceID's todos. Subtasks count on
+// their own, since they can be assigned separately.
+func (r *todoRepository) GetWorkload(userID uuid.UUID, workspaceID *uuid.UUID) ([]models.Workload, error) {
+	rows, err := r.db.Query(`
+	  SELECT t.assignee_id, COALESCE(u.name, ''), COALESCE(u.email, ''),
+	    count(*), count(*) FILTER (WHERE t.due_date < now())
+	  FROM todos t
+	  LEFT JOIN users u ON u.id = t.assignee_id
+	  WHERE (CASE WHEN $2::uuid IS NULL THEN t.user_id = $1 AND t.workspace_id IS NULL ELSE t.workspace_id = $2 END)
+	    AND t.deleted_at IS NULL AND NOT t.completed
+	  GROUP BY t.assignee_id, u.name, u.email
+	  ORDER BY t.assignee_id IS NULL, count(*) DESC, lower(u.name)
+	`, userID, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workload: %w", err)
+	}
+	defer rows.Close()
+
+	workload := []models.Workload{}
+	for rows.Next() {
+		var w models.Workload
+		if err := rows.Scan(&w.AssigneeID, &w.Name, &w.Email, &w.Open, &w.Overdue); err != nil {
+			return nil, fmt.Errorf("failed to scan workload: %w", err)
+		}
+		workload = append(workload, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return workload, nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/google/uuid"
+)
+
+type AttachmentRepository interface {
+	CreateAttachment(a *models.Attachment) error
+	GetAttachments(todoID uuid.UUID) ([]models.Attachment, error)
+	GetAttachment(todoID uuid.UUID, id uuid.UUID) (*models.Attachment, error)
+	DeleteAttachment(todoID uuid.UUID, id uuid.UUID) error
+	GetQueuedBlobDeletions(limit int) ([]string, error)
+	ClearBlobDeletion(storageKey string) error
+}
+
+type attachmentRepository struct {
+	db *sql.DB
+}
+
+func NewAttachmentRepository(db *sql.DB) AttachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+const attachmentColumns = `id, todo_id, uploaded_by, filename, content_type, size, sha256, storage_key, created_at`
+
+func scanAttachment(row rowScanner, a *models.Attachment) error {
+	return row.Scan(&a.ID, &a.TodoID, &a.UploadedBy, &a.Filename, &a.ContentType, &a.Size, &a.SHA256, &a.StorageKey, &a.CreatedAt)
+}
+
+func (r *attachmentRepository) CreateAttachment(a *models.Attachment) error {
+	a.ID = uuid.New()
+	a.CreatedAt = time.Now()
+	_, err := r.db.Exec(`
+	  INSERT INTO todo_attachments (`+attachmentColumns+`)
+	  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, a.ID, a.TodoID, a.UploadedBy, a.Filename, a.ContentType, a.Size, a.SHA256, a.StorageKey, a.CreatedAt)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return models.ErrTodoNotFound
+		}
+		return fmt.Errorf("failed to create attachment: %w", err)
+	}
+	return nil
+}
+
+// GetAttachments lists the todo's attachments, oldest first.
+func (r *attachmentRepository) GetAttachments(todoID uuid.UUID) ([]models.Attachment, error) {
+	rows, err := r.db.Query(`
+	  SELECT `+attachmentColumns+`
+	  FROM todo_attachments
+	  WHERE todo_id = $1
+	  ORDER BY created_at, id
+	`, todoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []models.Attachment{}
+	for rows.Next() {
+		var a models.Attachment
+		if err := scanAttachment(rows, &a); err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return attachments, nil
+}
+
+func (r *attachmentRepository) GetAttachment(todoID uuid.UUID, id uuid.UUID) (*models.Attachment, error) {
+	var a models.Attachment
+	err := scanAttachment(r.db.QueryRow(`
+	  SELECT `+attachmentColumns+` FROM todo_attachments WHERE id = $1 AND todo_id = $2
+	`, id, todoID), &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrAttachmentNotFound
+		}
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	return &a, nil
+}
+
+// DeleteAttachment removes the attachment's row. Its blob is queued for the
+// sweeper by a trigger, as it is when a todo's attachments cascade away.
+func (r *attachmentRepository) DeleteAttachment(todoID uuid.UUID, id uuid.UUID) error {
+	res, err := r.db.Exec(`DELETE FROM todo_attachments WHERE id = $1 AND todo_id = $2`, id, todoID)
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrAttachmentNotFound
+	}
+	return nil
+}
+
+// GetQueuedBlobDeletions returns up to limit storage keys whose blobs are
+// waiting to be deleted, oldest first.
+func (r *attachmentRepository) GetQueuedBlobDeletions(limit int) ([]string, error) {
+	rows, err := r.db.Query(`
+	  SELECT storage_key FROM blob_deletions ORDER BY queued_at LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query blob deletions: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to scan blob deletion: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return keys, nil
+}
+
+// ClearBlobDeletion takes a key off the queue once its blob is gone.
+func (r *attachmentRepository) ClearBlobDeletion(storageKey string) error {
+	if _, err := r.db.Exec(`DELETE FROM blob_deletions WHERE storage_key = $1`, storageKey); err != nil {
+		return fmt.Errorf("failed to clear blob deletion: %w", err)
+	}
+	return nil
+}
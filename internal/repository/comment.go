@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type CommentRepository interface {
+	CreateComment(comment *models.Comment, mentions []uuid.UUID) error
+	GetComments(todoID uuid.UUID, page models.PageRequest) ([]models.Comment, error)
+	GetComment(todoID uuid.UUID, id uuid.UUID) (*models.Comment, error)
+	UpdateComment(comment *models.Comment, mentions []uuid.UUID) error
+	DeleteComment(todoID uuid.UUID, id uuid.UUID) error
+}
+
+type commentRepository struct {
+	db *sql.DB
+}
+
+func NewCommentRepository(db *sql.DB) CommentRepository {
+	return &commentRepository{db: db}
+}
+
+// commentListQuery selects comments as c with their author's name.
+const commentListQuery = `
+	  SELECT c.id, c.todo_id, c.author_id, c.body, c.created_at, c.updated_at, c.edited_at, COALESCE(u.name, '')
+	  FROM todo_comments c
+	  LEFT JOIN users u ON u.id = c.author_id
+`
+
+func scanComment(row rowScanner, c *models.Comment) error {
+	return row.Scan(&c.ID, &c.TodoID, &c.AuthorID, &c.Body, &c.CreatedAt, &c.UpdatedAt, &c.EditedAt, &c.AuthorName)
+}
+
+// setMentions replaces the comment's mentions with userIDs.
+func setMentions(tx *sql.Tx, commentID uuid.UUID, userIDs []uuid.UUID) error {
+	if _, err := tx.Exec(`DELETE FROM comment_mentions WHERE comment_id = $1`, commentID); err != nil {
+		return fmt.Errorf("failed to clear mentions: %w", err)
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+	ids := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		ids[i] = id.String()
+	}
+	_, err := tx.Exec(`
+	  INSERT INTO comment_mentions (comment_id, user_id)
+	  SELECT $1, unnest($2::uuid[])
+	  ON CONFLICT DO NOTHING
+	`, commentID, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to store mentions: %w", err)
+	}
+	return nil
+}
+
+// hydrateMentions fills in the users each comment mentions.
+func (r *commentRepository) hydrateMentions(comments ...*models.Comment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+	ids := make([]string, len(comments))
+	byID := make(map[uuid.UUID]*models.Comment, len(comments))
+	for i, c := range comments {
+		ids[i] = c.ID.String()
+		c.Mentions = []models.Mention{}
+		byID[c.ID] = c
+	}
+	rows, err := r.db.Query(`
+	  SELECT m.comment_id, u.id, u.name, u.email
+	  FROM comment_mentions m
+	  JOIN users u ON u.id = m.user_id
+	  WHERE m.comment_id = ANY($1::uuid[])
+	  ORDER BY lower(u.name)
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to query mentions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var commentID uuid.UUID
+		var m models.Mention
+		if err := rows.Scan(&commentID, &m.UserID, &m.Name, &m.Email); err != nil {
+			return fmt.Errorf("failed to scan mention: %w", err)
+		}
+		if c, ok := byID[commentID]; ok {
+			c.Mentions = append(c.Mentions, m)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("row iteration error: %w", err)
+	}
+	return nil
+}
+
+func (r *commentRepository) CreateComment(comment *models.Comment, mentions []uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	comment.ID = uuid.New()
+	comment.CreatedAt = time.Now()
+	comment.UpdatedAt = comment.CreatedAt
+	_, err = tx.Exec(`
+	  INSERT INTO todo_comments (id, todo_id, author_id, body, created_at, updated_at)
+	  VALUES ($1, $2, $3, $4, $5, $6)
+	`, comment.ID, comment.TodoID, comment.AuthorID, comment.Body, comment.CreatedAt, comment.UpdatedAt)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return models.ErrTodoNotFound
+		}
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+	if err := setMentions(tx, comment.ID, mentions); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit comment: %w", err)
+	}
+	return r.hydrateMentions(comment)
+}
+
+// GetComments returns a page of the todo's comments, oldest first. The
+// cursor holds the created_at and id of the last comment seen.
+func (r *commentRepository) GetComments(todoID uuid.UUID, page models.PageRequest) ([]models.Comment, error) {
+	var b queryBuilder
+	b.where("c.todo_id = " + b.arg(todoID))
+	if c := page.Cursor; c != nil {
+		if len(c.Values) != 1 {
+			return nil, models.ErrInvalidCursor
+		}
+		b.where(fmt.Sprintf("(c.created_at, c.id) > (%s::timestamptz, %s::uuid)", b.arg(c.Values[0]), b.arg(c.ID)))
+	}
+	rows, err := r.db.Query(commentListQuery+b.whereClause()+
+		" ORDER BY c.created_at, c.id LIMIT "+b.arg(page.Limit), b.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comments: %w", err)
+	}
+	defer rows.Close()
+
+	comments := []models.Comment{}
+	for rows.Next() {
+		var c models.Comment
+		if err := scanComment(rows, &c); err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	ptrs := make([]*models.Comment, len(comments))
+	for i := range comments {
+		ptrs[i] = &comments[i]
+	}
+	if err := r.hydrateMentions(ptrs...); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// CommentCursor builds the cursor that continues a thread after c.
+func CommentCursor(c *models.Comment) models.Cursor {
+	return models.Cursor{Values: []string{timeValue(c.CreatedAt)}, ID: c.ID}
+}
+
+func (r *commentRepository) GetComment(todoID uuid.UUID, id uuid.UUID) (*models.Comment, error) {
+	var c models.Comment
+	err := scanComment(r.db.QueryRow(commentListQuery+`WHERE c.id = $1 AND c.todo_id = $2`, id, todoID), &c)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("failed to get comment: %w", err)
+	}
+	if err := r.hydrateMentions(&c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// UpdateComment stores the comment's new body and mentions and marks it as
+// edited.
+func (r *commentRepository) UpdateComment(comment *models.Comment, mentions []uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	res, err := tx.Exec(`
+	  UPDATE todo_comments SET body = $1, updated_at = $2, edited_at = $2
+	  WHERE id = $3 AND todo_id = $4
+	`, comment.Body, now, comment.ID, comment.TodoID)
+	if err != nil {
+		return fmt.Errorf("failed to update comment: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrCommentNotFound
+	}
+	if err := setMentions(tx, comment.ID, mentions); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit comment update: %w", err)
+	}
+	comment.UpdatedAt = now
+	comment.EditedAt = &now
+	return r.hydrateMentions(comment)
+}
+
+func (r *commentRepository) DeleteComment(todoID uuid.UUID, id uuid.UUID) error {
+	res, err := r.db.Exec(`DELETE FROM todo_comments WHERE id = $1 AND todo_id = $2`, id, todoID)
+	if err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrCommentNotFound
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/google/uuid"
+)
+
+// createTestUser adds a user with the given name and an email derived
+// from it.
+func createTestUser(t *testing.T, db *sql.DB, name string) models.User {
+	t.Helper()
+	user := models.User{ID: uuid.New(), Name: name, Email: strings.ToLower(name) + "@example.com", Password: "x"}
+	if err := NewUserRepository(db).CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
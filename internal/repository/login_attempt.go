@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/google/uuid"
+)
+
+type LoginAttemptRepository interface {
+	RecordLoginAttempt(attempt *models.LoginAttempt) error
+	RecentFailuresByEmail(email string, since time.Time) (int, *time.Time, error)
+	RecentFailuresByIP(ip string, since time.Time) (int, *time.Time, error)
+	DeleteLoginAttemptsBefore(cutoff time.Time) (int64, error)
+}
+
+type loginAttemptRepository struct {
+	db *sql.DB
+}
+
+func NewLoginAttemptRepository(db *sql.DB) LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+func (r *loginAttemptRepository) RecordLoginAttempt(a *models.LoginAttempt) error {
+	a.ID = uuid.New()
+	a.CreatedAt = time.Now()
+	_, err := r.db.Exec(`
+	  INSERT INTO login_attempts (id, email, user_id, ip, success, reason, created_at)
+	  VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
+	`, a.ID, a.Email, a.UserID, a.IP, a.Success, a.Reason, a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record login attempt: %w", err)
+	}
+	return nil
+}
+
+// RecentFailuresByEmail counts failures for email since the later of since
+// and its last successful login, and returns when the latest one happened.
+// Attempts rejected because of a lockout don't count, so hammering a locked
+// account doesn't keep extending the lockout.
+func (r *loginAttemptRepository) RecentFailuresByEmail(email string, since time.Time) (int, *time.Time, error) {
+	return r.recentFailures(`
+	  SELECT count(*), max(created_at)
+	  FROM login_attempts
+	  WHERE email = $1 AND NOT success AND reason IS DISTINCT FROM 'locked' AND created_at > $2
+	    AND created_at > COALESCE((SELECT max(created_at) FROM login_attempts
+	                               WHERE email = $1 AND success), '-infinity')
+	`, email, since)
+}
+
+// RecentFailuresByIP counts failures from ip since since. Unlike the
+// per-account count it isn't reset by a success, which an attacker could
+// get by logging into their own account.
+func (r *loginAttemptRepository) RecentFailuresByIP(ip string, since time.Time) (int, *time.Time, error) {
+	return r.recentFailures(`
+	  SELECT count(*), max(created_at)
+	  FROM login_attempts
+	  WHERE ip = $1 AND NOT success AND reason IS DISTINCT FROM 'locked' AND created_at > $2
+	`, ip, since)
+}
+
+func (r *loginAttemptRepository) recentFailures(query string, args ...interface{}) (int, *time.Time, error) {
+	var count int
+	var last *time.Time
+	err := r.db.QueryRow(query, args...).Scan(&count, &last)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to count login failures: %w", err)
+	}
+	return count, last, nil
+}
+
+// DeleteLoginAttemptsBefore removes attempts recorded before cutoff and
+// returns how many there were.
+func (r *loginAttemptRepository) DeleteLoginAttemptsBefore(cutoff time.Time) (int64, error) {
+	res, err := r.db.Exec(`DELETE FROM login_attempts WHERE created_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete login attempts: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/google/uuid"
+)
+
+type OIDCRepository interface {
+	CreateState(state *models.OIDCState) error
+	ConsumeState(stateHash string) (*models.OIDCState, error)
+	GetIdentity(issuer, subject string) (*models.UserIdentity, error)
+	CreateIdentity(identity *models.UserIdentity) error
+}
+
+type oidcRepository struct {
+	db *sql.DB
+}
+
+func NewOIDCRepository(db *sql.DB) OIDCRepository {
+	return &oidcRepository{db: db}
+}
+
+func (r *oidcRepository) CreateState(st *models.OIDCState) error {
+	st.CreatedAt = time.Now()
+	// abandoned logins are cleared out as new ones start
+	if _, err := r.db.Exec(`DELETE FROM oidc_states WHERE expires_at < now()`); err != nil {
+		return fmt.Errorf("failed to prune oidc states: %w", err)
+	}
+	_, err := r.db.Exec(`
+	  INSERT INTO oidc_states (state_hash, provider, code_verifier, nonce, expires_at, created_at)
+	  VALUES ($1, $2, $3, $4, $5, $6)
+	`, st.StateHash, st.Provider, st.CodeVerifier, st.Nonce, st.ExpiresAt, st.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create oidc state: %w", err)
+	}
+	return nil
+}
+
+// ConsumeState deletes and returns an unexpired state, so each can only be
+// used once.
+func (r *oidcRepository) ConsumeState(stateHash string) (*models.OIDCState, error) {
+	var st models.OIDCState
+	err := r.db.QueryRow(`
+	  DELETE FROM oidc_states WHERE state_hash = $1 AND expires_at > now()
+	  RETURNING state_hash, provider, code_verifier, nonce, expires_at, created_at
+	`, stateHash).Scan(&st.StateHash, &st.Provider, &st.CodeVerifier, &st.Nonce, &st.ExpiresAt, &st.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrInvalidOIDCState
+		}
+		return nil, fmt.Errorf("failed to consume oidc state: %w", err)
+	}
+	return &st, nil
+}
+
+func (r *oidcRepository) GetIdentity(issuer, subject string) (*models.UserIdentity, error) {
+	var id models.UserIdentity
+	err := r.db.QueryRow(`
+	  SELECT id, user_id, provider, issuer, subject, COALESCE(email, ''), created_at
+	  FROM user_identities
+	  WHERE issuer = $1 AND subject = $2
+	`, issuer, subject).Scan(&id.ID, &id.UserID, &id.Provider, &id.Issuer, &id.Subject, &id.Email, &id.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user identity: %w", err)
+	}
+	return &id, nil
+}
+
+func (r *oidcRepository) CreateIdentity(id *models.UserIdentity) error {
+	id.ID = uuid.New()
+	id.CreatedAt = time.Now()
+	_, err := r.db.Exec(`
+	  INSERT INTO user_identities (id, user_id, provider, issuer, subject, email, created_at)
+	  VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
+	`, id.ID, id.UserID, id.Provider, id.Issuer, id.Subject, id.Email, id.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to link user identity: %w", err)
+	}
+	return nil
+}
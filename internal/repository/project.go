@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/google/uuid"
+)
+
+type ProjectRepository interface {
+	CreateProject(project *models.Project) error
+	GetProjectsByUser(userID uuid.UUID, workspaceID *uuid.UUID, includeArchived bool) ([]models.Project, error)
+	GetProjectByIDForUser(userID uuid.UUID, id uuid.UUID) (*models.Project, error)
+	UpdateProjectForUser(userID uuid.UUID, id uuid.UUID, req *models.UpdateProjectRequest) (*models.Project, error)
+	DeleteProjectForUser(userID uuid.UUID, id uuid.UUID, policy models.ProjectDeletePolicy) error
+	GetProjectAccess(userID uuid.UUID, id uuid.UUID) (uuid.UUID, models.ShareRole, error)
+	GetSharedProjects(userID uuid.UUID) ([]models.SharedProject, error)
+}
+
+type projectRepository struct {
+	db *sql.DB
+}
+
+func NewProjectRepository(db *sql.DB) ProjectRepository {
+	return &projectRepository{db: db}
+}
+
+const projectColumns = `p.id, p.user_id, p.workspace_id, p.name, COALESCE(p.color, ''), p.archived, p.sort_order, p.created_at, p.updated_at,
+	(SELECT count(*) FROM todos t WHERE t.project_id = p.id AND t.deleted_at IS NULL)`
+
+// scanProject reads projectColumns into p, followed by any extra
+// destinations.
+func scanProject(row rowScanner, p *models.Project, extra ...interface{}) error {
+	dest := []interface{}{&p.ID, &p.UserID, &p.WorkspaceID, &p.Name, &p.Color, &p.Archived, &p.SortOrder, &p.CreatedAt, &p.UpdatedAt, &p.TodoCount}
+	return row.Scan(append(dest, extra...)...)
+}
+
+func (r *projectRepository) CreateProject(project *models.Project) error {
+	now := time.Now()
+	project.ID = uuid.New()
+	project.CreatedAt = now
+	project.UpdatedAt = now
+	_, err := r.db.Exec(`
+	  INSERT INTO projects (id, user_id, workspace_id, name, color, archived, sort_order, created_at, updated_at)
+	  VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9)
+	`, project.ID, project.UserID, project.WorkspaceID, project.Name, project.Color, project.Archived, project.SortOrder, project.CreatedAt, project.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create project: %w", err)
+	}
+	return nil
+}
+
+// GetProjectsByUser lists the user's personal projects, or with
+// workspaceID all of the workspace's projects.
+func (r *projectRepository) GetProjectsByUser(userID uuid.UUID, workspaceID *uuid.UUID, includeArchived bool) ([]models.Project, error) {
+	rows, err := r.db.Query(`
+	  SELECT `+projectColumns+`
+	  FROM projects p
+	  WHERE (CASE WHEN $3::uuid IS NULL THEN p.user_id = $1 AND p.workspace_id IS NULL ELSE p.workspace_id = $3 END)
+	    AND ($2 OR NOT p.archived)
+	  ORDER BY p.archived, p.sort_order, lower(p.name)
+	`, userID, includeArchived, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user projects: %w", err)
+	}
+	defer rows.Close()
+
+	projects := []models.Project{}
+	for rows.Next() {
+		var p models.Project
+		if err := scanProject(rows, &p); err != nil {
+			return nil, fmt.Errorf("failed to scan project: %w", err)
+		}
+		projects = append(projects, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return projects, nil
+}
+
+func (r *projectRepository) GetProjectByIDForUser(userID uuid.UUID, id uuid.UUID) (*models.Project, error) {
+	var p models.Project
+	err := scanProject(r.db.QueryRow(`
+	  SELECT `+projectColumns+`
+	  FROM projects p
+	  WHERE p.id = $1 AND p.user_id = $2
+	`, id, userID), &p)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("failed to get user project by id: %w", err)
+	}
+	return &p, nil
+}
+
+func (r *projectRepository) UpdateProjectForUser(userID uuid.UUID, id uuid.UUID, req *models.UpdateProjectRequest) (*models.Project, error) {
+	existing, err := r.GetProjectByIDForUser(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		existing.Name = *req.Name
+	}
+	if req.Color != nil {
+		existing.Color = *req.Color
+	}
+	if req.Archived != nil {
+		existing.Archived = *req.Archived
+	}
+	if req.SortOrder != nil {
+		existing.SortOrder = *req.SortOrder
+	}
+	existing.UpdatedAt = time.Now()
+	res, err := r.db.Exec(`
+	  UPDATE projects
+	  SET name = $1, color = NULLIF($2, ''), archived = $3, sort_order = $4, updated_at = $5
+	  WHERE id = $6 AND user_id = $7
+	`, existing.Name, existing.Color, existing.Archived, existing.SortOrder, existing.UpdatedAt, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user project: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return nil, models.ErrProjectNotFound
+	}
+	return existing, nil
+}
+
+// DeleteProjectForUser removes the project. With DeleteCascade its todos,
+// whoever created them, are moved to the trash; either way the foreign key
+// files whatever is left of them under the inbox.
+func (r *projectRepository) DeleteProjectForUser(userID uuid.UUID, id uuid.UUID, policy models.ProjectDeletePolicy) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if policy == models.DeleteCascade {
+		_, err := tx.Exec(`
+		  UPDATE todos SET deleted_at = now()
+		  WHERE project_id = $1 AND deleted_at IS NULL
+		`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete project todos: %w", err)
+		}
+	}
+	res, err := tx.Exec(`DELETE FROM projects WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user project: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrProjectNotFound
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit project delete: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/danieldzansi/todo-api/internal/dbtest"
+	models "github.com/danieldzansi/todo-api/internal/model"
+)
+
+func TestCompletingRecurringTodoCopiesSubtasks(t *testing.T) {
+	db := dbtest.Open(t)
+	todos := NewTodoRepository(db)
+	user := createTestUser(t, db, "Owner")
+
+	rule := "FREQ=DAILY"
+	todo := &models.Todo{Title: "Water plants", UserID: user.ID, Recurrence: &rule}
+	if err := todos.CreateTodo(todo); err != nil {
+		t.Fatal(err)
+	}
+	subtask := &models.Todo{Title: "Ferns", UserID: user.ID, ParentID: &todo.ID, Priority: models.PriorityHigh, Tags: []string{"Garden"}}
+	if err := todos.CreateTodo(subtask); err != nil {
+		t.Fatal(err)
+	}
+
+	next := func(*models.Todo) (*models.Todo, error) {
+		return &models.Todo{Title: todo.Title, UserID: user.ID, Recurrence: &rule}, nil
+	}
+	completed, err := todos.ToggleTodoCompleteForUser(user.ID, todo.ID, false, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if completed.NextOccurrence == nil || completed.Recurrence != nil {
+		t.Fatalf("next = %v, recurrence = %v; want a next occurrence owning the rule", completed.NextOccurrence, completed.Recurrence)
+	}
+	copies, err := todos.GetSubtasksForUser(user.ID, completed.NextOccurrence.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(copies) != 1 {
+		t.Fatalf("next occurrence has %d subtasks, want 1", len(copies))
+	}
+	got := copies[0]
+	if got.Completed || got.Priority != models.PriorityHigh || len(got.Tags) != 1 || got.Tags[0] != "Garden" {
+		t.Errorf("copied subtask = completed %v, priority %v, tags %v; want open, high, [Garden]", got.Completed, got.Priority, got.Tags)
+	}
+}
+
+func TestCompletingRecurringTodoRollsBackOnError(t *testing.T) {
+	db := dbtest.Open(t)
+	todos := NewTodoRepository(db)
+	user := createTestUser(t, db, "Owner")
+
+	rule := "FREQ=DAILY"
+	todo := &models.Todo{Title: "Water plants", UserID: user.ID, Recurrence: &rule}
+	if err := todos.CreateTodo(todo); err != nil {
+		t.Fatal(err)
+	}
+	failed := errors.New("no next occurrence")
+	_, err := todos.ToggleTodoCompleteForUser(user.ID, todo.ID, false, func(*models.Todo) (*models.Todo, error) {
+		return nil, failed
+	})
+	if err != failed {
+		t.Fatalf("err = %v, want %v", err, failed)
+	}
+	got, err := todos.GetTodoByIDForUser(user.ID, todo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Completed {
+		t.Error("todo was completed although its next occurrence failed")
+	}
+}
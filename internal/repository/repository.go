@@ -17,10 +17,25 @@ type TodoRepository interface {
 	DeleteTodo(id uuid.UUID) error
 	ToggleTodoComplete(id uuid.UUID) (*models.Todo, error)
 	GetAllTodosByUser(userID uuid.UUID) ([]models.Todo, error)
+	ListTodosByUser(userID uuid.UUID, filter models.TodoFilter, page models.PageRequest) ([]models.Todo, error)
+	SearchTodosByUser(userID uuid.UUID, workspaceID *uuid.UUID, q string, limit int) ([]models.TodoSearchResult, error)
 	GetTodoByIDForUser(userID uuid.UUID, id uuid.UUID) (*models.Todo, error)
 	UpdateTodoForUser(userID uuid.UUID, id uuid.UUID, req *models.UpdateTodoRequest) (*models.Todo, error)
 	DeleteTodoForUser(userID uuid.UUID, id uuid.UUID) error
-	ToggleTodoCompleteForUser(userID uuid.UUID, id uuid.UUID) (*models.Todo, error)
+	ToggleTodoCompleteForUser(userID uuid.UUID, id uuid.UUID, cascade bool, next func(completed *models.Todo) (*models.Todo, error)) (*models.Todo, error)
+	MoveTodoForUser(userID uuid.UUID, id uuid.UUID, projectID *uuid.UUID) (*models.Todo, error)
+	GetSubtasksForUser(userID uuid.UUID, parentID uuid.UUID) ([]models.Todo, error)
+	ReorderSubtasksForUser(userID uuid.UUID, parentID uuid.UUID, ids []uuid.UUID) error
+	GetTrashByUser(userID uuid.UUID, workspaceID *uuid.UUID) ([]models.Todo, error)
+	RestoreTodoForUser(userID uuid.UUID, workspaceID *uuid.UUID, id uuid.UUID) (*models.Todo, error)
+	PurgeTodoForUser(userID uuid.UUID, workspaceID *uuid.UUID, id uuid.UUID) error
+	EmptyTrashForUser(userID uuid.UUID, workspaceID *uuid.UUID) (int64, error)
+	PurgeTrashedBefore(cutoff time.Time) (int64, error)
+	GetTodoAccess(userID uuid.UUID, id uuid.UUID) (uuid.UUID, models.ShareRole, error)
+	GetSharedTodos(userID uuid.UUID, projectID *uuid.UUID) ([]models.SharedTodo, error)
+	AssignTodo(userID uuid.UUID, id uuid.UUID, assigneeID *uuid.UUID) (*models.Todo, error)
+	GetAssignedTodos(userID uuid.UUID, workspaceID *uuid.UUID) ([]models.Todo, error)
+	GetWorkload(userID uuid.UUID, workspaceID *uuid.UUID) ([]models.Workload, error)
 }
 
 type UserRepository interface {
@@ -28,11 +43,57 @@ type UserRepository interface {
 	GetUserByEmail(email string) (*models.User, error)
 	GetUserByID(id uuid.UUID) (*models.User, error)
 	GetAllUsers() ([]models.User, error)
+	UpdatePassword(id uuid.UUID, passwordHash string) error
+	MarkEmailVerified(id uuid.UUID) error
+	UpdateName(id uuid.UUID, name string) error
+	UpdateEmail(id uuid.UUID, email string) error
+	DeleteUser(id uuid.UUID) error
+	AnonymizeUser(id uuid.UUID) error
 }
 
 type todoRepository struct {
 	db *sql.DB
 }
+
+// todoColumns is the select list read by scanTodo.
+const todoColumns = `id, title, description, completed, priority, due_date, user_id, project_id, parent_id, position, recurrence, created_at, updated_at, deleted_at, workspace_id, assignee_id`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTodo reads todoColumns into t, followed by any extra destinations.
+func scanTodo(row rowScanner, t *models.Todo, extra ...interface{}) error {
+	dest := []interface{}{&t.ID, &t.Title, &t.Description, &t.Completed, &t.Priority, &t.DueDate, &t.UserID, &t.ProjectID, &t.ParentID, &t.Position, &t.Recurrence, &t.CreatedAt, &t.UpdatedAt, &t.DeletedAt, &t.WorkspaceID, &t.AssigneeID}
+	return row.Scan(append(dest, extra...)...)
+}
+
+// queryTodos runs a query selecting todoColumns and returns the hydrated
+// todos.
+func (r *todoRepository) queryTodos(query string, args ...interface{}) ([]models.Todo, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query todos: %w", err)
+	}
+	defer rows.Close()
+
+	var todos []models.Todo
+	for rows.Next() {
+		var t models.Todo
+		if err := scanTodo(rows, &t); err != nil {
+			return nil, fmt.Errorf("failed to scan todo: %w", err)
+		}
+		todos = append(todos, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	if err := r.hydrate(todoPtrs(todos)...); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
 type userRepository struct {
 	db *sql.DB
 }
@@ -52,13 +113,24 @@ func (r *userRepository) CreateUser(user models.User) error {
 	return err
 }
 
+// userColumns is the select list read by scanUser.
+const userColumns = `id, name, email, password, email_verified_at, totp_enabled_at, created_at, updated_at`
+
+func scanUser(row rowScanner, u *models.User) error {
+	return row.Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.EmailVerifiedAt, &u.TOTPEnabledAt, &u.CreatedAt, &u.UpdatedAt)
+}
+
+// GetUserByEmail finds the user by email address, ignoring case. Should
+// accounts from before lookups ignored case differ only in case, the exact
+// match wins.
 func (r *userRepository) GetUserByEmail(email string) (*models.User, error) {
 	var u models.User
-	err := r.db.QueryRow(`
-		SELECT id, name, email, password, created_at, updated_at
-		FROM users
-		WHERE email = $1
-	`, email).Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.CreatedAt, &u.UpdatedAt)
+	err := scanUser(r.db.QueryRow(`
+	  SELECT `+userColumns+` FROM users
+	  WHERE lower(email) = lower($1)
+	  ORDER BY email = $1 DESC, created_at
+	  LIMIT 1
+	`, email), &u)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, models.ErrUserNotFound
@@ -70,7 +142,7 @@ func (r *userRepository) GetUserByEmail(email string) (*models.User, error) {
 
 func (r *userRepository) GetAllUsers() ([]models.User, error) {
 	rows, err := r.db.Query(`
-		SELECT id, name, email, created_at, updated_at
+		SELECT ` + userColumns + `
 		FROM users
 		ORDER BY created_at DESC
 	`)
@@ -82,7 +154,7 @@ func (r *userRepository) GetAllUsers() ([]models.User, error) {
 	var users []models.User
 	for rows.Next() {
 		var u models.User
-		if err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.CreatedAt, &u.UpdatedAt); err != nil {
+		if err := scanUser(rows, &u); err != nil {
 			return nil, fmt.Errorf("failed to scan user: %w", err)
 		}
 		users = append(users, u)
@@ -97,11 +169,7 @@ func (r *userRepository) GetAllUsers() ([]models.User, error) {
 
 func (r *userRepository) GetUserByID(id uuid.UUID) (*models.User, error) {
 	var u models.User
-	err := r.db.QueryRow(`
-		SELECT id, name, email, password, created_at, updated_at
-		FROM users
-		WHERE id = $1
-	`, id).Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.CreatedAt, &u.UpdatedAt)
+	err := scanUser(r.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = $1`, id), &u)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, models.ErrUserNotFound
@@ -111,116 +179,380 @@ func (r *userRepository) GetUserByID(id uuid.UUID) (*models.User, error) {
 	return &u, nil
 }
 
+func (r *userRepository) UpdatePassword(id uuid.UUID, passwordHash string) error {
+	res, err := r.db.Exec(`
+		UPDATE users SET password = $1, updated_at = now()
+		WHERE id = $2
+	`, passwordHash, id)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrUserNotFound
+	}
+	return nil
+}
+
+// MarkEmailVerified records that the user proved they own their email. It
+// keeps the original time if the address was already verified.
+func (r *userRepository) MarkEmailVerified(id uuid.UUID) error {
+	res, err := r.db.Exec(`
+		UPDATE users SET email_verified_at = COALESCE(email_verified_at, now()), updated_at = now()
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) UpdateName(id uuid.UUID, name string) error {
+	res, err := r.db.Exec(`
+		UPDATE users SET name = $1, updated_at = now()
+		WHERE id = $2
+	`, name, id)
+	if err != nil {
+		return fmt.Errorf("failed to update name: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateEmail switches the user to an address they just proved they own,
+// so it is stored as verified.
+func (r *userRepository) UpdateEmail(id uuid.UUID, email string) error {
+	res, err := r.db.Exec(`
+		UPDATE users SET email = $1, email_verified_at = now(), updated_at = now()
+		WHERE id = $2
+	`, email, id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return models.ErrUserAlreadyExists
+		}
+		return fmt.Errorf("failed to update email: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrUserNotFound
+	}
+	return nil
+}
+
+// checkNotLastAdmin fails with ErrLastAdmin if removing the user would leave
+// nobody with the admin role.
+func checkNotLastAdmin(tx *sql.Tx, id uuid.UUID) error {
+	var others bool
+	var isAdmin bool
+	err := tx.QueryRow(`
+	  SELECT
+	    EXISTS (SELECT 1 FROM user_roles ur JOIN roles r ON r.id = ur.role_id WHERE r.name = $1 AND ur.user_id = $2),
+	    EXISTS (SELECT 1 FROM user_roles ur JOIN roles r ON r.id = ur.role_id WHERE r.name = $1 AND ur.user_id <> $2)
+	`, models.RoleAdmin, id).Scan(&isAdmin, &others)
+	if err != nil {
+		return fmt.Errorf("failed to count admins: %w", err)
+	}
+	if isAdmin && !others {
+		return models.ErrLastAdmin
+	}
+	return nil
+}
+
+// DeleteUser removes the user; their personal todos, projects, tags and
+// tokens go with them through ON DELETE CASCADE. Workspaces they were
+// alone in are deleted too, while what they created in shared workspaces
+// passes to another owner.
+func (r *userRepository) DeleteUser(id uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkNotLastAdmin(tx, id); err != nil {
+		return err
+	}
+	if err := leaveWorkspaces(tx, id); err != nil {
+		return err
+	}
+	res, err := tx.Exec(`DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrUserNotFound
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit user deletion: %w", err)
+	}
+	return nil
+}
+
+// AnonymizeUser turns the user into a tombstone that keeps their todos:
+// name, email and password are scrubbed, and everything that could sign in
+// as them (sessions, tokens, second factor, linked identities, roles,
+// workspace memberships) is removed.
+func (r *userRepository) AnonymizeUser(id uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkNotLastAdmin(tx, id); err != nil {
+		return err
+	}
+	if err := leaveWorkspaces(tx, id); err != nil {
+		return err
+	}
+	// an empty hash never matches, so the account can't log in again
+	res, err := tx.Exec(`
+	  UPDATE users
+	  SET name = 'Deleted user', email = 'deleted-' || id || '@deleted.invalid', password = '',
+	      email_verified_at = NULL, totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL,
+	      tokens_valid_after = now(), updated_at = now()
+	  WHERE id = $1
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to anonymize user: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrUserNotFound
+	}
+	for _, q := range []string{
+		`DELETE FROM refresh_tokens WHERE user_id = $1`,
+		`DELETE FROM user_tokens WHERE user_id = $1`,
+		`DELETE FROM personal_access_tokens WHERE user_id = $1`,
+		`DELETE FROM recovery_codes WHERE user_id = $1`,
+		`DELETE FROM user_identities WHERE user_id = $1`,
+		`DELETE FROM user_roles WHERE user_id = $1`,
+		`DELETE FROM todo_shares WHERE user_id = $1`,
+		`UPDATE todos SET assignee_id = NULL WHERE assignee_id = $1`,
+		`UPDATE login_attempts SET user_id = NULL, email = '' WHERE user_id = $1`,
+	} {
+		if _, err := tx.Exec(q, id); err != nil {
+			return fmt.Errorf("failed to anonymize user: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit user anonymization: %w", err)
+	}
+	return nil
+}
+
 func (r *todoRepository) CreateTodo(todo *models.Todo) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertTodo(tx, todo); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit todo: %w", err)
+	}
+	return nil
+}
+
+// insertTodo stores a new todo and its tags inside tx, filling in the id,
+// timestamps and position.
+func insertTodo(tx *sql.Tx, todo *models.Todo) error {
 	query := `
-	  INSERT INTO todos(id,title,description,completed,due_date,user_id,created_at,updated_at)
-	  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	  INSERT INTO todos(id,title,description,completed,priority,due_date,user_id,project_id,parent_id,position,recurrence,created_at,updated_at,workspace_id,assignee_id)
+	  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9,
+	          COALESCE((SELECT max(position) + 1 FROM todos WHERE parent_id = $9), 0), $10, $11, $12, $13, $14)
+	  RETURNING position
 	`
 	now := time.Now()
 	todo.ID = uuid.New()
 	todo.CreatedAt = now
 	todo.UpdatedAt = now
+	todo.Tags = models.NormalizeTagNames(todo.Tags)
 
-	_, err := r.db.Exec(query,
+	err := tx.QueryRow(query,
 		todo.ID,
 		todo.Title,
 		todo.Description,
 		todo.Completed,
+		todo.Priority,
 		todo.DueDate,
 		todo.UserID,
+		todo.ProjectID,
+		todo.ParentID,
+		todo.Recurrence,
 		todo.CreatedAt,
 		todo.UpdatedAt,
-	)
+		todo.WorkspaceID,
+		todo.AssigneeID,
+	).Scan(&todo.Position)
 	if err != nil {
 		return fmt.Errorf("failed to create todo: %w", err)
 	}
-	return nil
+	return setTodoTags(tx, todo.UserID, todo.ID, todo.Tags)
 }
 
 func (r *todoRepository) GetAllTodos() ([]models.Todo, error) {
-	query := `
-	  SELECT id, title, description, completed, due_date, created_at, updated_at
+	return r.queryTodos(`
+	  SELECT ` + todoColumns + `
 	  FROM todos
+	  WHERE deleted_at IS NULL
 	  ORDER BY created_at DESC
-	`
-	rows, err := r.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query todos: %w", err)
-	}
-	defer rows.Close()
-
-	var todos []models.Todo
-	for rows.Next() {
-		var t models.Todo
-		if err := rows.Scan(&t.ID, &t.Title, &t.Description, &t.Completed, &t.DueDate, &t.CreatedAt, &t.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan todo: %w", err)
-		}
-		todos = append(todos, t)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("row iteration error: %w", err)
-	}
-	return todos, nil
+	`)
 }
 
 func (r *todoRepository) GetAllTodosByUser(userID uuid.UUID) ([]models.Todo, error) {
-	query := `
-	  SELECT id, title, description, completed, due_date, user_id, created_at, updated_at
+	return r.queryTodos(`
+	  SELECT `+todoColumns+`
 	  FROM todos
-	  WHERE user_id = $1
+	  WHERE user_id = $1 AND deleted_at IS NULL
 	  ORDER BY created_at DESC
+	`, userID)
+}
+
+// ListTodosByUser returns up to page.Limit todos matching filter after the
+// cursor, in the order they were walked: the filter's sort order, or its
+// reverse for backward cursors.
+func (r *todoRepository) ListTodosByUser(userID uuid.UUID, filter models.TodoFilter, page models.PageRequest) ([]models.Todo, error) {
+	keys := sortKeysFor(filter.Sort)
+	if c := page.Cursor; c != nil && (c.Sort != filter.Sort || len(c.Values) != len(keys)) {
+		return nil, models.ErrInvalidCursor
+	}
+
+	var b queryBuilder
+	if filter.WorkspaceID != nil {
+		b.where("workspace_id = " + b.arg(*filter.WorkspaceID))
+	} else {
+		b.where("user_id = " + b.arg(userID))
+		b.where("workspace_id IS NULL")
+	}
+	b.where("deleted_at IS NULL")
+	applyTodoFilter(&b, filter)
+	order := applyCursor(&b, keys, page.Cursor)
+	return r.queryTodos(`
+	  SELECT `+todoColumns+`
+	  FROM todos`+b.whereClause()+order+" LIMIT "+b.arg(page.Limit), b.args...)
+}
+
+// sqlHTMLEscape wraps a text expression so it evaluates to the text
+// escaped for HTML. Highlights are returned as HTML with <mark> tags, so
+// the user's own text has to be escaped before ts_headline adds them.
+func sqlHTMLEscape(expr string) string {
+	return "replace(replace(replace(replace(replace(" + expr +
+		", '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '\"', '&quot;'), '''', '&#39;')"
+}
+
+// SearchTodosByUser ranks the user's personal todos, or with workspaceID
+// the workspace's todos, against q using the search_vector column, best
+// matches first.
+func (r *todoRepository) SearchTodosByUser(userID uuid.UUID, workspaceID *uuid.UUID, q string, limit int) ([]models.TodoSearchResult, error) {
+	tsq := prefixTSQuery(q)
+	if tsq == "" {
+		return nil, models.ErrInvalidSearchQuery
+	}
+	query := `
+	  SELECT ` + todoColumns + `,
+	         ts_rank(search_vector, q) AS rank,
+	         ts_headline('english', ` + sqlHTMLEscape("title") + `, q, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true'),
+	         ts_headline('english', ` + sqlHTMLEscape("coalesce(description, '')") + `, q, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5')
+	  FROM todos, to_tsquery('english', $2) AS q
+	  WHERE (CASE WHEN $4::uuid IS NULL THEN user_id = $1 AND workspace_id IS NULL ELSE workspace_id = $4 END)
+	    AND deleted_at IS NULL AND search_vector @@ q
+	  ORDER BY rank DESC, created_at DESC, id DESC
+	  LIMIT $3
 	`
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.Query(query, userID, tsq, limit, workspaceID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query user todos: %w", err)
+		return nil, fmt.Errorf("failed to search user todos: %w", err)
 	}
 	defer rows.Close()
 
-	var todos []models.Todo
+	results := []models.TodoSearchResult{}
 	for rows.Next() {
-		var t models.Todo
-		if err := rows.Scan(&t.ID, &t.Title, &t.Description, &t.Completed, &t.DueDate, &t.UserID, &t.CreatedAt, &t.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan todo: %w", err)
+		var res models.TodoSearchResult
+		if err := scanTodo(rows, &res.Todo, &res.Rank, &res.TitleHighlight, &res.Snippet); err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
 		}
-		todos = append(todos, t)
+		results = append(results, res)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("row iteration error: %w", err)
 	}
-	return todos, nil
+	ptrs := make([]*models.Todo, len(results))
+	for i := range results {
+		ptrs[i] = &results[i].Todo
+	}
+	if err := r.hydrate(ptrs...); err != nil {
+		return nil, err
+	}
+	return results, nil
 }
 
 func (r *todoRepository) GetTodoByID(id uuid.UUID) (*models.Todo, error) {
 	query := `
-	  SELECT id, title, description, completed, due_date, created_at, updated_at
+	  SELECT ` + todoColumns + `
 	  FROM todos
-	  WHERE id = $1
+	  WHERE id = $1 AND deleted_at IS NULL
 	`
 	var t models.Todo
-	err := r.db.QueryRow(query, id).Scan(&t.ID, &t.Title, &t.Description, &t.Completed, &t.DueDate, &t.CreatedAt, &t.UpdatedAt)
+	err := scanTodo(r.db.QueryRow(query, id), &t)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, models.ErrTodoNotFound
 		}
 		return nil, fmt.Errorf("failed to get todo by id: %w", err)
 	}
+	if err := r.hydrate(&t); err != nil {
+		return nil, err
+	}
 	return &t, nil
 }
 
 func (r *todoRepository) GetTodoByIDForUser(userID uuid.UUID, id uuid.UUID) (*models.Todo, error) {
 	query := `
-	  SELECT id, title, description, completed, due_date, user_id, created_at, updated_at
+	  SELECT ` + todoColumns + `
 	  FROM todos
-	  WHERE id = $1 AND user_id = $2
+	  WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
 	`
 	var t models.Todo
-	err := r.db.QueryRow(query, id, userID).Scan(&t.ID, &t.Title, &t.Description, &t.Completed, &t.DueDate, &t.UserID, &t.CreatedAt, &t.UpdatedAt)
+	err := scanTodo(r.db.QueryRow(query, id, userID), &t)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, models.ErrTodoNotFound
 		}
 		return nil, fmt.Errorf("failed to get user todo by id: %w", err)
 	}
+	if err := r.hydrate(&t); err != nil {
+		return nil, err
+	}
 	return &t, nil
 }
 
@@ -236,18 +568,30 @@ func (r *todoRepository) UpdateTodo(id uuid.UUID, req *models.UpdateTodoRequest)
 	if req.Description != nil {
 		existing.Description = *req.Description
 	}
+	if req.Priority != nil {
+		existing.Priority = *req.Priority
+	}
 	if req.DueDate != nil {
 		existing.DueDate = req.DueDate
 	}
+	if req.ProjectID != nil {
+		existing.ProjectID = req.ProjectID
+	}
+	if req.AssigneeID != nil {
+		existing.AssigneeID = req.AssigneeID
+	}
+	if req.Recurrence != nil {
+		existing.Recurrence = nilIfEmpty(*req.Recurrence)
+	}
 	now := time.Now()
 	existing.UpdatedAt = now
 
 	query := `
 	  UPDATE todos
-	  SET title = $1, description = $2, due_date = $3, updated_at = $4
-	  WHERE id = $5
+	  SET title = $1, description = $2, priority = $3, due_date = $4, project_id = $5, recurrence = $6, assignee_id = $7, updated_at = $8
+	  WHERE id = $9 AND deleted_at IS NULL
 	`
-	res, err := r.db.Exec(query, existing.Title, existing.Description, existing.DueDate, existing.UpdatedAt, id)
+	res, err := r.db.Exec(query, existing.Title, existing.Description, existing.Priority, existing.DueDate, existing.ProjectID, existing.Recurrence, existing.AssigneeID, existing.UpdatedAt, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update todo: %w", err)
 	}
@@ -273,17 +617,39 @@ func (r *todoRepository) UpdateTodoForUser(userID uuid.UUID, id uuid.UUID, req *
 	if req.Description != nil {
 		existing.Description = *req.Description
 	}
+	if req.Priority != nil {
+		existing.Priority = *req.Priority
+	}
 	if req.DueDate != nil {
 		existing.DueDate = req.DueDate
 	}
+	if req.ProjectID != nil {
+		existing.ProjectID = req.ProjectID
+	}
+	if req.AssigneeID != nil {
+		existing.AssigneeID = req.AssigneeID
+	}
+	if req.Recurrence != nil {
+		existing.Recurrence = nilIfEmpty(*req.Recurrence)
+	}
+	if req.Tags != nil {
+		existing.Tags = models.NormalizeTagNames(*req.Tags)
+	}
 	now := time.Now()
 	existing.UpdatedAt = now
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 	  UPDATE todos
-	  SET title = $1, description = $2, due_date = $3, updated_at = $4
-	  WHERE id = $5 AND user_id = $6
+	  SET title = $1, description = $2, priority = $3, due_date = $4, project_id = $5, recurrence = $6, assignee_id = $7, updated_at = $8
+	  WHERE id = $9 AND user_id = $10 AND deleted_at IS NULL
 	`
-	res, err := r.db.Exec(query, existing.Title, existing.Description, existing.DueDate, existing.UpdatedAt, id, userID)
+	res, err := tx.Exec(query, existing.Title, existing.Description, existing.Priority, existing.DueDate, existing.ProjectID, existing.Recurrence, existing.AssigneeID, existing.UpdatedAt, id, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update user todo: %w", err)
 	}
@@ -294,30 +660,54 @@ func (r *todoRepository) UpdateTodoForUser(userID uuid.UUID, id uuid.UUID, req *
 	if affected == 0 {
 		return nil, models.ErrTodoNotFound
 	}
+	if req.ProjectID != nil {
+		// subtasks share their parent's project, as in MoveTodoForUser
+		_, err := tx.Exec(`
+		  UPDATE todos SET project_id = $1, updated_at = $2
+		  WHERE parent_id = $3 AND user_id = $4 AND deleted_at IS NULL
+		`, existing.ProjectID, existing.UpdatedAt, id, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to move subtasks: %w", err)
+		}
+	}
+	if req.Tags != nil {
+		if err := setTodoTags(tx, userID, id, existing.Tags); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit todo update: %w", err)
+	}
 	return existing, nil
 }
 
+// DeleteTodo moves the todo and its subtasks to the trash.
 func (r *todoRepository) DeleteTodo(id uuid.UUID) error {
-	query := `DELETE FROM todos WHERE id = $1`
-	res, err := r.db.Exec(query, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete todo: %w", err)
-	}
-	affected, err := res.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-	if affected == 0 {
-		return models.ErrTodoNotFound
-	}
-	return nil
+	return r.trashTodo(id, nil)
 }
 
+// DeleteTodoForUser moves the user's todo and its subtasks to the trash.
 func (r *todoRepository) DeleteTodoForUser(userID uuid.UUID, id uuid.UUID) error {
-	query := `DELETE FROM todos WHERE id = $1 AND user_id = $2`
-	res, err := r.db.Exec(query, id, userID)
+	return r.trashTodo(id, &userID)
+}
+
+// trashTodo soft deletes a live todo, owned by userID when it is set, then
+// its live subtasks with the same timestamp so a restore can bring them back
+// together.
+func (r *todoRepository) trashTodo(id uuid.UUID, userID *uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	res, err := tx.Exec(`
+	  UPDATE todos SET deleted_at = $1
+	  WHERE id = $2 AND ($3::uuid IS NULL OR user_id = $3) AND deleted_at IS NULL
+	`, now, id, userID)
 	if err != nil {
-		return fmt.Errorf("failed to delete user todo: %w", err)
+		return fmt.Errorf("failed to delete todo: %w", err)
 	}
 	affected, err := res.RowsAffected()
 	if err != nil {
@@ -326,6 +716,12 @@ func (r *todoRepository) DeleteTodoForUser(userID uuid.UUID, id uuid.UUID) error
 	if affected == 0 {
 		return models.ErrTodoNotFound
 	}
+	if _, err := tx.Exec(`UPDATE todos SET deleted_at = $1 WHERE parent_id = $2 AND deleted_at IS NULL`, now, id); err != nil {
+		return fmt.Errorf("failed to delete subtasks: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit todo delete: %w", err)
+	}
 	return nil
 }
 
@@ -340,7 +736,7 @@ func (r *todoRepository) ToggleTodoComplete(id uuid.UUID) (*models.Todo, error)
 	query := `
 	  UPDATE todos
 	  SET completed = $1, updated_at = $2
-	  WHERE id = $3
+	  WHERE id = $3 AND deleted_at IS NULL
 	`
 	res, err := r.db.Exec(query, existing.Completed, existing.UpdatedAt, id)
 	if err != nil {
@@ -356,19 +752,31 @@ func (r *todoRepository) ToggleTodoComplete(id uuid.UUID) (*models.Todo, error)
 	return existing, nil
 }
 
-func (r *todoRepository) ToggleTodoCompleteForUser(userID uuid.UUID, id uuid.UUID) (*models.Todo, error) {
+// ToggleTodoCompleteForUser flips the todo's completed flag. When cascade is
+// set and the todo becomes completed, its open subtasks are completed too.
+// When the todo becomes completed and next is set, the occurrence next
+// returns for it (if any) is stored in the same transaction and attached as
+// NextOccurrence, so a todo is never completed without its successor.
+func (r *todoRepository) ToggleTodoCompleteForUser(userID uuid.UUID, id uuid.UUID, cascade bool, next func(completed *models.Todo) (*models.Todo, error)) (*models.Todo, error) {
 	existing, err := r.GetTodoByIDForUser(userID, id)
 	if err != nil {
 		return nil, err
 	}
 	existing.Completed = !existing.Completed
 	existing.UpdatedAt = time.Now()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 	  UPDATE todos
 	  SET completed = $1, updated_at = $2
-	  WHERE id = $3 AND user_id = $4
+	  WHERE id = $3 AND user_id = $4 AND deleted_at IS NULL
 	`
-	res, err := r.db.Exec(query, existing.Completed, existing.UpdatedAt, id, userID)
+	res, err := tx.Exec(query, existing.Completed, existing.UpdatedAt, id, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to toggle user todo: %w", err)
 	}
@@ -379,5 +787,122 @@ func (r *todoRepository) ToggleTodoCompleteForUser(userID uuid.UUID, id uuid.UUI
 	if affected == 0 {
 		return nil, models.ErrTodoNotFound
 	}
+	if cascade && existing.Completed {
+		_, err := tx.Exec(`
+		  UPDATE todos
+		  SET completed = true, updated_at = $1
+		  WHERE parent_id = $2 AND user_id = $3 AND completed = false AND deleted_at IS NULL
+		`, existing.UpdatedAt, id, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to complete subtasks: %w", err)
+		}
+	}
+	if next != nil && existing.Completed {
+		occurrence, err := next(existing)
+		if err != nil {
+			return nil, err
+		}
+		if occurrence != nil {
+			if err := createNextOccurrence(tx, existing, occurrence); err != nil {
+				return nil, err
+			}
+			existing.NextOccurrence = occurrence
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit todo toggle: %w", err)
+	}
+	if existing.NextOccurrence != nil {
+		existing.Recurrence = nil
+	}
+	if err := r.attachProgress(existing); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// MoveTodoForUser sets the todo's project; a nil projectID moves it to the
+// inbox. Subtasks move along with their parent.
+func (r *todoRepository) MoveTodoForUser(userID uuid.UUID, id uuid.UUID, projectID *uuid.UUID) (*models.Todo, error) {
+	existing, err := r.GetTodoByIDForUser(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	existing.ProjectID = projectID
+	existing.UpdatedAt = time.Now()
+	query := `
+	  UPDATE todos
+	  SET project_id = $1, updated_at = $2
+	  WHERE (id = $3 OR parent_id = $3) AND user_id = $4 AND deleted_at IS NULL
+	`
+	res, err := r.db.Exec(query, existing.ProjectID, existing.UpdatedAt, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move user todo: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return nil, models.ErrTodoNotFound
+	}
 	return existing, nil
 }
+
+// createNextOccurrence stores next as the following occurrence of the
+// recurring todo completed, copying its subtasks (with their priority and
+// tags) as open items. The recurrence rule moves to next, so completing the
+// old todo again does not spawn a second copy.
+func createNextOccurrence(tx *sql.Tx, completed *models.Todo, next *models.Todo) error {
+	if err := insertTodo(tx, next); err != nil {
+		return err
+	}
+	rows, err := tx.Query(`SELECT id FROM todos WHERE parent_id = $1 AND deleted_at IS NULL ORDER BY position`, completed.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get subtasks: %w", err)
+	}
+	var subtaskIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan subtask: %w", err)
+		}
+		subtaskIDs = append(subtaskIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate subtasks: %w", err)
+	}
+
+	for _, id := range subtaskIDs {
+		copyID := uuid.New()
+		_, err := tx.Exec(`
+		  INSERT INTO todos (id, title, description, completed, priority, user_id, project_id, workspace_id, assignee_id, parent_id, position, created_at, updated_at)
+		  SELECT $1, title, description, false, priority, user_id, project_id, workspace_id, assignee_id, $2, position, $3, $3
+		  FROM todos
+		  WHERE id = $4
+		`, copyID, next.ID, next.CreatedAt, id)
+		if err != nil {
+			return fmt.Errorf("failed to copy subtask: %w", err)
+		}
+		_, err = tx.Exec(`
+		  INSERT INTO todo_tags (todo_id, tag_id)
+		  SELECT $1, tag_id FROM todo_tags WHERE todo_id = $2
+		`, copyID, id)
+		if err != nil {
+			return fmt.Errorf("failed to copy subtask tags: %w", err)
+		}
+	}
+	if _, err := tx.Exec(`UPDATE todos SET recurrence = NULL WHERE id = $1`, completed.ID); err != nil {
+		return fmt.Errorf("failed to end recurrence: %w", err)
+	}
+	return nil
+}
+
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type RoleRepository interface {
+	CreateRole(role *models.Role) error
+	GetRoles() ([]models.Role, error)
+	GetRoleByName(name string) (*models.Role, error)
+	UpdateRole(name string, req *models.UpdateRoleRequest) (*models.Role, error)
+	DeleteRole(name string) error
+	GetUserRoles(userID uuid.UUID) ([]models.Role, error)
+	SetUserRoles(userID uuid.UUID, names []string) error
+	AddUserRole(userID uuid.UUID, name string) error
+}
+
+type roleRepository struct {
+	db *sql.DB
+}
+
+func NewRoleRepository(db *sql.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+const roleColumns = `r.id, r.name, COALESCE(r.description, ''), r.permissions, r.builtin, r.created_at, r.updated_at`
+
+func scanRole(row rowScanner, role *models.Role) error {
+	return row.Scan(&role.ID, &role.Name, &role.Description, pq.Array(&role.Permissions), &role.Builtin, &role.CreatedAt, &role.UpdatedAt)
+}
+
+func (r *roleRepository) queryRoles(query string, args ...interface{}) ([]models.Role, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query roles: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []models.Role{}
+	for rows.Next() {
+		var role models.Role
+		if err := scanRole(rows, &role); err != nil {
+			return nil, fmt.Errorf("failed to scan role: %w", err)
+		}
+		roles = append(roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return roles, nil
+}
+
+func (r *roleRepository) CreateRole(role *models.Role) error {
+	now := time.Now()
+	role.ID = uuid.New()
+	role.CreatedAt = now
+	role.UpdatedAt = now
+	if role.Permissions == nil {
+		role.Permissions = []string{}
+	}
+	_, err := r.db.Exec(`
+	  INSERT INTO roles (id, name, description, permissions, builtin, created_at, updated_at)
+	  VALUES ($1, $2, NULLIF($3, ''), $4, false, $5, $6)
+	`, role.ID, role.Name, role.Description, pq.Array(role.Permissions), role.CreatedAt, role.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return models.ErrRoleAlreadyExists
+		}
+		return fmt.Errorf("failed to create role: %w", err)
+	}
+	return nil
+}
+
+func (r *roleRepository) GetRoles() ([]models.Role, error) {
+	return r.queryRoles(`SELECT ` + roleColumns + ` FROM roles r ORDER BY r.builtin DESC, r.name`)
+}
+
+func (r *roleRepository) GetRoleByName(name string) (*models.Role, error) {
+	var role models.Role
+	err := scanRole(r.db.QueryRow(`SELECT `+roleColumns+` FROM roles r WHERE r.name = $1`, name), &role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrRoleNotFound
+		}
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	return &role, nil
+}
+
+func (r *roleRepository) UpdateRole(name string, req *models.UpdateRoleRequest) (*models.Role, error) {
+	existing, err := r.GetRoleByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if existing.Builtin {
+		return nil, models.ErrBuiltinRole
+	}
+	if req.Description != nil {
+		existing.Description = *req.Description
+	}
+	if req.Permissions != nil {
+		existing.Permissions = *req.Permissions
+	}
+	existing.UpdatedAt = time.Now()
+	_, err = r.db.Exec(`
+	  UPDATE roles SET description = NULLIF($1, ''), permissions = $2, updated_at = $3
+	  WHERE id = $4
+	`, existing.Description, pq.Array(existing.Permissions), existing.UpdatedAt, existing.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update role: %w", err)
+	}
+	return existing, nil
+}
+
+func (r *roleRepository) DeleteRole(name string) error {
+	existing, err := r.GetRoleByName(name)
+	if err != nil {
+		return err
+	}
+	if existing.Builtin {
+		return models.ErrBuiltinRole
+	}
+	if _, err := r.db.Exec(`DELETE FROM roles WHERE id = $1`, existing.ID); err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+	return nil
+}
+
+func (r *roleRepository) GetUserRoles(userID uuid.UUID) ([]models.Role, error) {
+	return r.queryRoles(`
+	  SELECT `+roleColumns+`
+	  FROM roles r JOIN user_roles ur ON ur.role_id = r.id
+	  WHERE ur.user_id = $1
+	  ORDER BY r.name
+	`, userID)
+}
+
+// SetUserRoles replaces the user's roles. It fails with ErrRoleNotFound if
+// any name is unknown, and with ErrLastAdmin if it would leave nobody with
+// the admin role.
+func (r *roleRepository) SetUserRoles(userID uuid.UUID, names []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var allKnown bool
+	err = tx.QueryRow(`
+	  SELECT (SELECT count(*) FROM roles WHERE name = ANY($1))
+	       = (SELECT count(DISTINCT n) FROM unnest($1::text[]) n)
+	`, pq.Array(names)).Scan(&allKnown)
+	if err != nil {
+		return fmt.Errorf("failed to look up roles: %w", err)
+	}
+	if !allKnown {
+		return models.ErrRoleNotFound
+	}
+	if _, err := tx.Exec(`DELETE FROM user_roles WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to clear user roles: %w", err)
+	}
+	_, err = tx.Exec(`
+	  INSERT INTO user_roles (user_id, role_id)
+	  SELECT $1, id FROM roles WHERE name = ANY($2)
+	`, userID, pq.Array(names))
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return models.ErrUserNotFound
+		}
+		return fmt.Errorf("failed to set user roles: %w", err)
+	}
+	var admins int
+	err = tx.QueryRow(`
+	  SELECT count(*) FROM user_roles ur JOIN roles r ON r.id = ur.role_id WHERE r.name = $1
+	`, models.RoleAdmin).Scan(&admins)
+	if err != nil {
+		return fmt.Errorf("failed to count admins: %w", err)
+	}
+	if admins == 0 {
+		return models.ErrLastAdmin
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit user roles: %w", err)
+	}
+	return nil
+}
+
+func (r *roleRepository) AddUserRole(userID uuid.UUID, name string) error {
+	role, err := r.GetRoleByName(name)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`
+	  INSERT INTO user_roles (user_id, role_id) VALUES ($1, $2)
+	  ON CONFLICT DO NOTHING
+	`, userID, role.ID)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return models.ErrUserNotFound
+		}
+		return fmt.Errorf("failed to add user role: %w", err)
+	}
+	return nil
+}
+
+// isForeignKeyViolation reports whether err is a Postgres
+// foreign_key_violation.
+func isForeignKeyViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23503"
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danieldzansi/todo-api/internal/dbtest"
+	models "github.com/danieldzansi/todo-api/internal/model"
+)
+
+func TestSearchEscapesHighlights(t *testing.T) {
+	db := dbtest.Open(t)
+	todos := NewTodoRepository(db)
+	user := createTestUser(t, db, "Searcher")
+
+	todo := &models.Todo{
+		Title:       `Invoice <img src=x onerror="alert(1)">`,
+		Description: `Send the invoice & <script>steal()</script> it`,
+		UserID:      user.ID,
+	}
+	if err := todos.CreateTodo(todo); err != nil {
+		t.Fatal(err)
+	}
+	results, err := todos.SearchTodosByUser(user.ID, nil, "invoice", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	got := results[0]
+	for _, s := range []string{got.TitleHighlight, got.Snippet} {
+		if strings.Contains(strings.NewReplacer("<mark>", "", "</mark>", "").Replace(s), "<") {
+			t.Errorf("highlight %q contains unescaped markup", s)
+		}
+	}
+	if !strings.Contains(got.TitleHighlight, "<mark>Invoice</mark>") || !strings.Contains(got.TitleHighlight, "&lt;img") {
+		t.Errorf("title highlight = %q", got.TitleHighlight)
+	}
+}
@@ -0,0 +1,295 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/google/uuid"
+)
+
+type ShareRepository interface {
+	UpsertShare(share *models.Share) error
+	GetTodoShares(todoID uuid.UUID) ([]models.Share, error)
+	GetProjectShares(projectID uuid.UUID) ([]models.Share, error)
+	DeleteTodoShare(todoID uuid.UUID, userID uuid.UUID) error
+	DeleteProjectShare(projectID uuid.UUID, userID uuid.UUID) error
+	GetInvitations(userID uuid.UUID) ([]models.Share, error)
+	AcceptInvitation(userID uuid.UUID, id uuid.UUID) (*models.Share, error)
+	DeclineInvitation(userID uuid.UUID, id uuid.UUID) error
+}
+
+type shareRepository struct {
+	db *sql.DB
+}
+
+func NewShareRepository(db *sql.DB) ShareRepository {
+	return &shareRepository{db: db}
+}
+
+const shareColumns = `id, todo_id, project_id, user_id, role, status, invited_by, created_at, responded_at`
+
+// shareListQuery selects shares as s, with the invitee's name and email,
+// the title of the shared todo or project and who sent the invitation.
+const shareListQuery = `
+	  SELECT s.id, s.todo_id, s.project_id, s.user_id, s.role, s.status, s.invited_by, s.created_at, s.responded_at,
+	    u.name, u.email, COALESCE(t.title, p.name, ''), COALESCE(i.name, '')
+	  FROM todo_shares s
+	  JOIN users u ON u.id = s.user_id
+	  LEFT JOIN todos t ON t.id = s.todo_id
+	  LEFT JOIN projects p ON p.id = s.project_id
+	  LEFT JOIN users i ON i.id = s.invited_by
+`
+
+// scanShare reads shareColumns into s, followed by any extra destinations.
+func scanShare(row rowScanner, s *models.Share, extra ...interface{}) error {
+	dest := []interface{}{&s.ID, &s.TodoID, &s.ProjectID, &s.UserID, &s.Role, &s.Status, &s.InvitedBy, &s.CreatedAt, &s.RespondedAt}
+	return row.Scan(append(dest, extra...)...)
+}
+
+func (r *shareRepository) queryShares(where string, args ...interface{}) ([]models.Share, error) {
+	rows, err := r.db.Query(shareListQuery+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shares: %w", err)
+	}
+	defer rows.Close()
+
+	shares := []models.Share{}
+	for rows.Next() {
+		var s models.Share
+		if err := scanShare(rows, &s, &s.UserName, &s.UserEmail, &s.Title, &s.InvitedByName); err != nil {
+			return nil, fmt.Errorf("failed to scan share: %w", err)
+		}
+		shares = append(shares, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return shares, nil
+}
+
+// UpsertShare invites share.UserID to a todo or project. If they were
+// already invited their role is changed instead, keeping the invitation's
+// status.
+func (r *shareRepository) UpsertShare(share *models.Share) error {
+	target := "todo_id"
+	if share.ProjectID != nil {
+		target = "project_id"
+	}
+	err := scanShare(r.db.QueryRow(`
+	  INSERT INTO todo_shares (id, todo_id, project_id, user_id, role, status, invited_by, created_at)
+	  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	  ON CONFLICT (`+target+`, user_id) DO UPDATE SET role = EXCLUDED.role
+	  RETURNING `+shareColumns+`
+	`, uuid.New(), share.TodoID, share.ProjectID, share.UserID, share.Role, models.SharePending, share.InvitedBy, time.Now()), share)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return models.ErrUserNotFound
+		}
+		return fmt.Errorf("failed to share: %w", err)
+	}
+	return nil
+}
+
+func (r *shareRepository) GetTodoShares(todoID uuid.UUID) ([]models.Share, error) {
+	return r.queryShares(`WHERE s.todo_id = $1 ORDER BY s.created_at`, todoID)
+}
+
+func (r *shareRepository) GetProjectShares(projectID uuid.UUID) ([]models.Share, error) {
+	return r.queryShares(`WHERE s.project_id = $1 ORDER BY s.created_at`, projectID)
+}
+
+func (r *shareRepository) deleteShare(query string, args ...interface{}) error {
+	res, err := r.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete share: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrShareNotFound
+	}
+	return nil
+}
+
+func (r *shareRepository) DeleteTodoShare(todoID uuid.UUID, userID uuid.UUID) error {
+	return r.deleteShare(`DELETE FROM todo_shares WHERE todo_id = $1 AND user_id = $2`, todoID, userID)
+}
+
+func (r *shareRepository) DeleteProjectShare(projectID uuid.UUID, userID uuid.UUID) error {
+	return r.deleteShare(`DELETE FROM todo_shares WHERE project_id = $1 AND user_id = $2`, projectID, userID)
+}
+
+// GetInvitations lists the invitations the user hasn't answered yet.
+func (r *shareRepository) GetInvitations(userID uuid.UUID) ([]models.Share, error) {
+	return r.queryShares(`WHERE s.user_id = $1 AND s.status = $2 ORDER BY s.created_at DESC`, userID, models.SharePending)
+}
+
+func (r *shareRepository) AcceptInvitation(userID uuid.UUID, id uuid.UUID) (*models.Share, error) {
+	var s models.Share
+	err := scanShare(r.db.QueryRow(`
+	  UPDATE todo_shares SET status = $1, responded_at = now()
+	  WHERE id = $2 AND user_id = $3 AND status = $4
+	  RETURNING `+shareColumns+`
+	`, models.ShareAccepted, id, userID, models.SharePending), &s)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrInvitationNotFound
+		}
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	return &s, nil
+}
+
+func (r *shareRepository) DeclineInvitation(userID uuid.UUID, id uuid.UUID) error {
+	err := r.deleteShare(`
+	  DELETE FROM todo_shares WHERE id = $1 AND user_id = $2 AND status = $3
+	`, id, userID, models.SharePending)
+	if err == models.ErrShareNotFound {
+		return models.ErrInvitationNotFound
+	}
+	return err
+}
+
+// GetTodoAccess returns the todo's owner and the user's role on it.
+// Workspace todos are open to the workspace's members: their creator and
+// the workspace's owners and admins count as owner, other members as
+// editor. Personal todos are the owner's, or shared through an accepted
+// share of the todo, its parent or its project. Todos the user can't see
+// are ErrTodoNotFound.
+func (r *todoRepository) GetTodoAccess(userID uuid.UUID, id uuid.UUID) (uuid.UUID, models.ShareRole, error) {
+	var ownerID uuid.UUID
+	var role sql.NullString
+	err := r.db.QueryRow(`
+	  SELECT t.user_id,
+	    CASE WHEN t.workspace_id IS NOT NULL THEN (
+	      SELECT CASE WHEN m.role IN ('owner', 'admin') OR t.user_id = $2 THEN 'owner' ELSE 'editor' END
+	      FROM workspace_members m
+	      WHERE m.workspace_id = t.workspace_id AND m.user_id = $2
+	    )
+	    WHEN t.user_id = $2 THEN 'owner' ELSE (
+	      SELECT s.role FROM todo_shares s
+	      WHERE s.user_id = $2 AND s.status = 'accepted'
+	        AND (s.todo_id IN (t.id, t.parent_id) OR s.project_id = t.project_id)
+	      ORDER BY s.role = 'editor' DESC
+	      LIMIT 1
+	    ) END
+	  FROM todos t
+	  WHERE t.id = $1 AND t.deleted_at IS NULL
+	`, id, userID).Scan(&ownerID, &role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, "", models.ErrTodoNotFound
+		}
+		return uuid.Nil, "", fmt.Errorf("failed to get todo access: %w", err)
+	}
+	if !role.Valid {
+		return uuid.Nil, "", models.ErrTodoNotFound
+	}
+	return ownerID, models.ShareRole(role.String), nil
+}
+
+// GetSharedTodos lists the top-level todos others have shared with the
+// user, directly or through a project, optionally only those in projectID.
+// A todo shared on its own is listed even if it is a subtask.
+func (r *todoRepository) GetSharedTodos(userID uuid.UUID, projectID *uuid.UUID) ([]models.SharedTodo, error) {
+	rows, err := r.db.Query(`
+	  SELECT `+todoColumns+`, role FROM (
+	    SELECT DISTINCT ON (t.id) t.*, s.role
+	    FROM todo_shares s
+	    JOIN todos t ON t.id = s.todo_id OR (t.project_id = s.project_id AND t.parent_id IS NULL)
+	    WHERE s.user_id = $1 AND s.status = 'accepted' AND t.user_id <> $1
+	      AND t.workspace_id IS NULL AND t.deleted_at IS NULL
+	    ORDER BY t.id, s.role = 'editor' DESC
+	  ) shared
+	  WHERE $2::uuid IS NULL OR project_id = $2
+	  ORDER BY created_at DESC, id DESC
+	`, userID, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shared todos: %w", err)
+	}
+	defer rows.Close()
+
+	todos := []models.SharedTodo{}
+	for rows.Next() {
+		var t models.SharedTodo
+		if err := scanTodo(rows, &t.Todo, &t.Role); err != nil {
+			return nil, fmt.Errorf("failed to scan todo: %w", err)
+		}
+		todos = append(todos, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	ptrs := make([]*models.Todo, len(todos))
+	for i := range todos {
+		ptrs[i] = &todos[i].Todo
+	}
+	if err := r.hydrate(ptrs...); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// GetProjectAccess returns the project's owner and the user's role on it,
+// following the same rules as GetTodoAccess. Projects the user can't see
+// are ErrProjectNotFound.
+func (r *projectRepository) GetProjectAccess(userID uuid.UUID, id uuid.UUID) (uuid.UUID, models.ShareRole, error) {
+	var ownerID uuid.UUID
+	var role sql.NullString
+	err := r.db.QueryRow(`
+	  SELECT p.user_id,
+	    CASE WHEN p.workspace_id IS NOT NULL THEN (
+	      SELECT CASE WHEN m.role IN ('owner', 'admin') OR p.user_id = $2 THEN 'owner' ELSE 'editor' END
+	      FROM workspace_members m
+	      WHERE m.workspace_id = p.workspace_id AND m.user_id = $2
+	    )
+	    WHEN p.user_id = $2 THEN 'owner' ELSE (
+	      SELECT s.role FROM todo_shares s
+	      WHERE s.project_id = p.id AND s.user_id = $2 AND s.status = 'accepted'
+	    ) END
+	  FROM projects p
+	  WHERE p.id = $1
+	`, id, userID).Scan(&ownerID, &role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, "", models.ErrProjectNotFound
+		}
+		return uuid.Nil, "", fmt.Errorf("failed to get project access: %w", err)
+	}
+	if !role.Valid {
+		return uuid.Nil, "", models.ErrProjectNotFound
+	}
+	return ownerID, models.ShareRole(role.String), nil
+}
+
+// GetSharedProjects lists the projects others have shared with the user.
+func (r *projectRepository) GetSharedProjects(userID uuid.UUID) ([]models.SharedProject, error) {
+	rows, err := r.db.Query(`
+	  SELECT `+projectColumns+`, s.role
+	  FROM projects p
+	  JOIN todo_shares s ON s.project_id = p.id
+	  WHERE s.user_id = $1 AND s.status = 'accepted' AND p.workspace_id IS NULL
+	  ORDER BY lower(p.name)
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shared projects: %w", err)
+	}
+	defer rows.Close()
+
+	projects := []models.SharedProject{}
+	for rows.Next() {
+		var p models.SharedProject
+		if err := scanProject(rows, &p.Project, &p.Role); err != nil {
+			return nil, fmt.Errorf("failed to scan project: %w", err)
+		}
+		projects = append(projects, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return projects, nil
+}
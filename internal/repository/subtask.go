@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// hydrate fills in the fields of each todo that live outside the todos row.
+func (r *todoRepository) hydrate(todos ...*models.Todo) error {
+	if err := r.attachTags(todos...); err != nil {
+		return err
+	}
+	return r.attachProgress(todos...)
+}
+
+// attachProgress sets Progress on each todo that has subtasks.
+func (r *todoRepository) attachProgress(todos ...*models.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+	ids := make([]string, len(todos))
+	byID := make(map[uuid.UUID]*models.Todo, len(todos))
+	for i, t := range todos {
+		ids[i] = t.ID.String()
+		t.Progress = nil
+		byID[t.ID] = t
+	}
+	rows, err := r.db.Query(`
+	  SELECT parent_id, count(*) FILTER (WHERE completed), count(*)
+	  FROM todos
+	  WHERE parent_id = ANY($1::uuid[]) AND deleted_at IS NULL
+	  GROUP BY parent_id
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to query subtask progress: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var parentID uuid.UUID
+		var done, total int
+		if err := rows.Scan(&parentID, &done, &total); err != nil {
+			return fmt.Errorf("failed to scan subtask progress: %w", err)
+		}
+		if t := byID[parentID]; t != nil {
+			t.Progress = models.NewProgress(done, total)
+		}
+	}
+	return rows.Err()
+}
+
+func (r *todoRepository) GetSubtasksForUser(userID uuid.UUID, parentID uuid.UUID) ([]models.Todo, error) {
+	todos, err := r.queryTodos(`
+	  SELECT `+todoColumns+`
+	  FROM todos
+	  WHERE parent_id = $1 AND user_id = $2 AND deleted_at IS NULL
+	  ORDER BY position, created_at
+	`, parentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if todos == nil {
+		todos = []models.Todo{}
+	}
+	return todos, nil
+}
+
+// ReorderSubtasksForUser rewrites subtask positions to follow ids, which must
+// name every subtask of the parent exactly once.
+func (r *todoRepository) ReorderSubtasksForUser(userID uuid.UUID, parentID uuid.UUID, ids []uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id FROM todos WHERE parent_id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE`, parentID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to query subtasks: %w", err)
+	}
+	current := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan subtask: %w", err)
+		}
+		current[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("row iteration error: %w", err)
+	}
+
+	if len(ids) != len(current) {
+		return models.ErrInvalidSubtaskOrder
+	}
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if !current[id] || seen[id] {
+			return models.ErrInvalidSubtaskOrder
+		}
+		seen[id] = true
+	}
+
+	now := time.Now()
+	for pos, id := range ids {
+		if _, err := tx.Exec(`UPDATE todos SET position = $1, updated_at = $2 WHERE id = $3`, pos, now, id); err != nil {
+			return fmt.Errorf("failed to reorder subtask: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit subtask order: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type TagRepository interface {
+	CreateTag(tag *models.Tag) error
+	GetTagsByUser(userID uuid.UUID) ([]models.Tag, error)
+	GetTagByIDForUser(userID uuid.UUID, id uuid.UUID) (*models.Tag, error)
+	UpdateTagForUser(userID uuid.UUID, id uuid.UUID, req *models.UpdateTagRequest) (*models.Tag, error)
+	DeleteTagForUser(userID uuid.UUID, id uuid.UUID) error
+}
+
+type tagRepository struct {
+	db *sql.DB
+}
+
+func NewTagRepository(db *sql.DB) TagRepository {
+	return &tagRepository{db: db}
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// isUniqueViolation reports whether err is a Postgres unique_violation.
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+func lowerAll(names []string) []string {
+	out := make([]string, len(names))
+	for i, n := range names {
+		out[i] = strings.ToLower(n)
+	}
+	return out
+}
+
+func (r *tagRepository) CreateTag(tag *models.Tag) error {
+	now := time.Now()
+	tag.ID = uuid.New()
+	tag.CreatedAt = now
+	tag.UpdatedAt = now
+	_, err := r.db.Exec(`
+	  INSERT INTO tags (id, user_id, name, color, created_at, updated_at)
+	  VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+	`, tag.ID, tag.UserID, tag.Name, tag.Color, tag.CreatedAt, tag.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return models.ErrTagAlreadyExists
+		}
+		return fmt.Errorf("failed to create tag: %w", err)
+	}
+	return nil
+}
+
+func (r *tagRepository) GetTagsByUser(userID uuid.UUID) ([]models.Tag, error) {
+	rows, err := r.db.Query(`
+	  SELECT t.id, t.user_id, t.name, COALESCE(t.color, ''), t.created_at, t.updated_at,
+	         (SELECT count(*) FROM todo_tags tt JOIN todos td ON td.id = tt.todo_id
+	          WHERE tt.tag_id = t.id AND td.deleted_at IS NULL)
+	  FROM tags t
+	  WHERE t.user_id = $1
+	  ORDER BY lower(t.name)
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var t models.Tag
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Color, &t.CreatedAt, &t.UpdatedAt, &t.TodoCount); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return tags, nil
+}
+
+func (r *tagRepository) GetTagByIDForUser(userID uuid.UUID, id uuid.UUID) (*models.Tag, error) {
+	var t models.Tag
+	err := r.db.QueryRow(`
+	  SELECT t.id, t.user_id, t.name, COALESCE(t.color, ''), t.created_at, t.updated_at,
+	         (SELECT count(*) FROM todo_tags tt JOIN todos td ON td.id = tt.todo_id
+	          WHERE tt.tag_id = t.id AND td.deleted_at IS NULL)
+	  FROM tags t
+	  WHERE t.id = $1 AND t.user_id = $2
+	`, id, userID).Scan(&t.ID, &t.UserID, &t.Name, &t.Color, &t.CreatedAt, &t.UpdatedAt, &t.TodoCount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrTagNotFound
+		}
+		return nil, fmt.Errorf("failed to get user tag by id: %w", err)
+	}
+	return &t, nil
+}
+
+func (r *tagRepository) UpdateTagForUser(userID uuid.UUID, id uuid.UUID, req *models.UpdateTagRequest) (*models.Tag, error) {
+	existing, err := r.GetTagByIDForUser(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		existing.Name = *req.Name
+	}
+	if req.Color != nil {
+		existing.Color = *req.Color
+	}
+	existing.UpdatedAt = time.Now()
+	res, err := r.db.Exec(`
+	  UPDATE tags
+	  SET name = $1, color = NULLIF($2, ''), updated_at = $3
+	  WHERE id = $4 AND user_id = $5
+	`, existing.Name, existing.Color, existing.UpdatedAt, id, userID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, models.ErrTagAlreadyExists
+		}
+		return nil, fmt.Errorf("failed to update user tag: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return nil, models.ErrTagNotFound
+	}
+	return existing, nil
+}
+
+func (r *tagRepository) DeleteTagForUser(userID uuid.UUID, id uuid.UUID) error {
+	res, err := r.db.Exec(`DELETE FROM tags WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user tag: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrTagNotFound
+	}
+	return nil
+}
+
+// setTodoTags replaces the tags on a todo, creating any of the user's tags
+// that don't exist yet.
+func setTodoTags(db execer, userID uuid.UUID, todoID uuid.UUID, names []string) error {
+	if _, err := db.Exec(`DELETE FROM todo_tags WHERE todo_id = $1`, todoID); err != nil {
+		return fmt.Errorf("failed to clear todo tags: %w", err)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	_, err := db.Exec(`
+	  INSERT INTO tags (user_id, name)
+	  SELECT $1, unnest($2::text[])
+	  ON CONFLICT (user_id, lower(name)) DO NOTHING
+	`, userID, pq.Array(names))
+	if err != nil {
+		return fmt.Errorf("failed to create tags: %w", err)
+	}
+	_, err = db.Exec(`
+	  INSERT INTO todo_tags (todo_id, tag_id)
+	  SELECT $1, id FROM tags WHERE user_id = $2 AND lower(name) = ANY($3)
+	`, todoID, userID, pq.Array(lowerAll(names)))
+	if err != nil {
+		return fmt.Errorf("failed to tag todo: %w", err)
+	}
+	return nil
+}
+
+// attachTags fills in Tags on each todo with a single query.
+func (r *todoRepository) attachTags(todos ...*models.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+	ids := make([]string, len(todos))
+	byID := make(map[uuid.UUID]*models.Todo, len(todos))
+	for i, t := range todos {
+		ids[i] = t.ID.String()
+		t.Tags = []string{}
+		byID[t.ID] = t
+	}
+	rows, err := r.db.Query(`
+	  SELECT tt.todo_id, tg.name
+	  FROM todo_tags tt
+	  JOIN tags tg ON tg.id = tt.tag_id
+	  WHERE tt.todo_id = ANY($1::uuid[])
+	  ORDER BY lower(tg.name)
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to query todo tags: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var todoID uuid.UUID
+		var name string
+		if err := rows.Scan(&todoID, &name); err != nil {
+			return fmt.Errorf("failed to scan todo tag: %w", err)
+		}
+		if t := byID[todoID]; t != nil {
+			t.Tags = append(t.Tags, name)
+		}
+	}
+	return rows.Err()
+}
+
+// todoPtrs returns pointers into todos for use with attachTags.
+func todoPtrs(todos []models.Todo) []*models.Todo {
+	ptrs := make([]*models.Todo, len(todos))
+	for i := range todos {
+		ptrs[i] = &todos[i]
+	}
+	return ptrs
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/lib/pq"
+)
+
+// queryBuilder collects WHERE conditions and their positional arguments.
+type queryBuilder struct {
+	conds []string
+	args  []interface{}
+}
+
+// arg registers v and returns its placeholder.
+func (b *queryBuilder) arg(v interface{}) string {
+	b.args = append(b.args, v)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+func (b *queryBuilder) where(cond string) {
+	b.conds = append(b.conds, cond)
+}
+
+func (b *queryBuilder) whereClause() string {
+	if len(b.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conds, " AND ")
+}
+
+// sortKey is one column of a todo ordering. expr must never be NULL so that
+// keyset comparisons stay well defined.
+type sortKey struct {
+	expr  string
+	cast  string
+	desc  bool
+	value func(t *models.Todo) string
+}
+
+func timeValue(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func createdAtKey(desc bool) sortKey {
+	return sortKey{expr: "created_at", cast: "timestamptz", desc: desc, value: func(t *models.Todo) string {
+		return timeValue(t.CreatedAt)
+	}}
+}
+
+// dueDateKey sorts todos without a due date last in either direction.
+func dueDateKey(desc bool) sortKey {
+	missing := "infinity"
+	if desc {
+		missing = "-infinity"
+	}
+	return sortKey{expr: "COALESCE(due_date, '" + missing + "'::timestamptz)", cast: "timestamptz", desc: desc, value: func(t *models.Todo) string {
+		if t.DueDate == nil {
+			return missing
+		}
+		return timeValue(*t.DueDate)
+	}}
+}
+
+func titleKey(desc bool) sortKey {
+	return sortKey{expr: "title", cast: "text", desc: desc, value: func(t *models.Todo) string {
+		return t.Title
+	}}
+}
+
+func priorityKey(desc bool) sortKey {
+	return sortKey{expr: "priority", cast: "smallint", desc: desc, value: func(t *models.Todo) string {
+		return strconv.Itoa(int(t.Priority))
+	}}
+}
+
+var todoSorts = map[models.TodoSort][]sortKey{
+	models.SortCreatedAtDesc: {createdAtKey(true)},
+	models.SortCreatedAt:     {createdAtKey(false)},
+	models.SortDueDate:       {dueDateKey(false)},
+	models.SortDueDateDesc:   {dueDateKey(true)},
+	models.SortTitle:         {titleKey(false)},
+	models.SortTitleDesc:     {titleKey(true)},
+	models.SortPriorityDesc:  {priorityKey(true), dueDateKey(false)},
+	models.SortPriority:      {priorityKey(false), dueDateKey(false)},
+}
+
+func sortKeysFor(sort models.TodoSort) []sortKey {
+	if keys, ok := todoSorts[sort]; ok {
+		return keys
+	}
+	return todoSorts[models.SortCreatedAtDesc]
+}
+
+// TodoCursor builds the cursor pointing at t for the given sort.
+func TodoCursor(sort models.TodoSort, t *models.Todo, backward bool) models.Cursor {
+	keys := sortKeysFor(sort)
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		values[i] = k.value(t)
+	}
+	return models.Cursor{Sort: sort, Values: values, ID: t.ID, Backward: backward}
+}
+
+// applyTodoFilter adds the filter's conditions to b.
+func applyTodoFilter(b *queryBuilder, f models.TodoFilter) {
+	if !f.IncludeSubtasks {
+		b.where("parent_id IS NULL")
+	}
+	if f.Completed != nil {
+		b.where("completed = " + b.arg(*f.Completed))
+	}
+	if f.Priority != nil {
+		b.where("priority = " + b.arg(int(*f.Priority)))
+	}
+	if f.DueBefore != nil {
+		b.where("due_date < " + b.arg(*f.DueBefore))
+	}
+	if f.DueAfter != nil {
+		b.where("due_date > " + b.arg(*f.DueAfter))
+	}
+	if f.Overdue {
+		b.where("due_date < now() AND completed = false")
+	}
+	if f.CreatedSince != nil {
+		b.where("created_at >= " + b.arg(*f.CreatedSince))
+	}
+	if f.ProjectID != nil {
+		b.where("project_id = " + b.arg(*f.ProjectID))
+	} else if f.Inbox {
+		b.where("project_id IS NULL")
+	}
+	if f.AssigneeID != nil {
+		b.where("assignee_id = " + b.arg(*f.AssigneeID))
+	} else if f.Unassigned {
+		b.where("assignee_id IS NULL")
+	}
+	if len(f.Tags) > 0 {
+		names := lowerAll(models.NormalizeTagNames(f.Tags))
+		tagged := `SELECT %s FROM todo_tags tt JOIN tags tg ON tg.id = tt.tag_id
+		  WHERE tt.todo_id = todos.id AND lower(tg.name) = ANY(%s)`
+		if f.TagMatch == models.TagMatchAll {
+			b.where(fmt.Sprintf("("+tagged+") = %s", "count(DISTINCT lower(tg.name))", b.arg(pq.Array(names)), b.arg(len(names))))
+		} else {
+			b.where(fmt.Sprintf("EXISTS ("+tagged+")", "1", b.arg(pq.Array(names))))
+		}
+	}
+}
+
+// applyCursor adds the keyset condition that skips everything up to and
+// including the cursor position, and returns the ORDER BY clause to walk
+// the list from there.
+func applyCursor(b *queryBuilder, keys []sortKey, cursor *models.Cursor) string {
+	backward := cursor != nil && cursor.Backward
+	// the id tie-breaker follows the primary key's direction
+	idKey := sortKey{expr: "id", cast: "uuid", desc: keys[0].desc}
+	all := append(append([]sortKey{}, keys...), idKey)
+
+	order := make([]string, len(all))
+	for i, k := range all {
+		dir := "ASC"
+		if k.desc != backward {
+			dir = "DESC"
+		}
+		order[i] = k.expr + " " + dir
+	}
+
+	if cursor != nil {
+		values := append(append([]string{}, cursor.Values...), cursor.ID.String())
+		var alts []string
+		for i, k := range all {
+			var parts []string
+			for j := 0; j < i; j++ {
+				parts = append(parts, fmt.Sprintf("%s = %s::%s", all[j].expr, b.arg(values[j]), all[j].cast))
+			}
+			op := ">"
+			if k.desc != backward {
+				op = "<"
+			}
+			parts = append(parts, fmt.Sprintf("%s %s %s::%s", k.expr, op, b.arg(values[i]), k.cast))
+			alts = append(alts, "("+strings.Join(parts, " AND ")+")")
+		}
+		b.where("(" + strings.Join(alts, " OR ") + ")")
+	}
+	return " ORDER BY " + strings.Join(order, ", ")
+}
+
+// prefixTSQuery turns free text into a to_tsquery expression that matches
+// every word as a prefix, so "pla gro" finds "Plan groceries". Anything but
+// letters and digits is dropped, which keeps tsquery operators out of user
+// input. It returns "" when no words remain.
+func prefixTSQuery(q string) string {
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
+}
@@ -0,0 +1,365 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type TokenRepository interface {
+	CreateRefreshToken(token *models.RefreshToken) error
+	GetRefreshTokenByHash(hash string) (*models.RefreshToken, error)
+	RotateRefreshToken(oldID uuid.UUID, next *models.RefreshToken) error
+	RevokeRefreshTokenFamily(familyID uuid.UUID) error
+	RevokeAccessToken(jti uuid.UUID, userID uuid.UUID, expiresAt time.Time) error
+	IsAccessTokenRevoked(jti uuid.UUID) (bool, error)
+	RevokeAllForUser(userID uuid.UUID, at time.Time) error
+	GetTokensValidAfter(userID uuid.UUID) (*time.Time, error)
+	CreateUserToken(token *models.UserToken) error
+	GetUserToken(purpose models.TokenPurpose, hash string) (*models.UserToken, error)
+	ConsumeUserToken(purpose models.TokenPurpose, hash string) (*models.UserToken, error)
+	LastUserTokenAt(userID uuid.UUID, purpose models.TokenPurpose) (*time.Time, error)
+	CreatePersonalToken(token *models.PersonalAccessToken) error
+	GetPersonalTokensByUser(userID uuid.UUID) ([]models.PersonalAccessToken, error)
+	GetPersonalTokenByHash(hash string) (*models.PersonalAccessToken, error)
+	RevokePersonalTokenForUser(userID uuid.UUID, id uuid.UUID) error
+	TouchPersonalToken(id uuid.UUID) error
+}
+
+type tokenRepository struct {
+	db *sql.DB
+}
+
+func NewTokenRepository(db *sql.DB) TokenRepository {
+	return &tokenRepository{db: db}
+}
+
+func insertRefreshToken(db execer, t *models.RefreshToken) error {
+	t.ID = uuid.New()
+	t.CreatedAt = time.Now()
+	_, err := db.Exec(`
+	  INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, created_at)
+	  VALUES ($1, $2, $3, $4, $5, $6)
+	`, t.ID, t.UserID, t.FamilyID, t.TokenHash, t.ExpiresAt, t.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return nil
+}
+
+func (r *tokenRepository) CreateRefreshToken(token *models.RefreshToken) error {
+	return insertRefreshToken(r.db, token)
+}
+
+func (r *tokenRepository) GetRefreshTokenByHash(hash string) (*models.RefreshToken, error) {
+	var t models.RefreshToken
+	err := r.db.QueryRow(`
+	  SELECT id, user_id, family_id, token_hash, expires_at, revoked_at, replaced_by, created_at
+	  FROM refresh_tokens
+	  WHERE token_hash = $1
+	`, hash).Scan(&t.ID, &t.UserID, &t.FamilyID, &t.TokenHash, &t.ExpiresAt, &t.RevokedAt, &t.ReplacedBy, &t.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrInvalidRefreshToken
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	return &t, nil
+}
+
+// RotateRefreshToken revokes oldID in favour of next. If oldID was already
+// revoked, e.g. by a concurrent request presenting the same token, nothing
+// is stored and ErrRefreshTokenReused is returned.
+func (r *tokenRepository) RotateRefreshToken(oldID uuid.UUID, next *models.RefreshToken) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertRefreshToken(tx, next); err != nil {
+		return err
+	}
+	res, err := tx.Exec(`
+	  UPDATE refresh_tokens
+	  SET revoked_at = now(), replaced_by = $1
+	  WHERE id = $2 AND revoked_at IS NULL
+	`, next.ID, oldID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrRefreshTokenReused
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit token rotation: %w", err)
+	}
+	return nil
+}
+
+func (r *tokenRepository) RevokeRefreshTokenFamily(familyID uuid.UUID) error {
+	_, err := r.db.Exec(`
+	  UPDATE refresh_tokens SET revoked_at = now()
+	  WHERE family_id = $1 AND revoked_at IS NULL
+	`, familyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+	return nil
+}
+
+func (r *tokenRepository) RevokeAccessToken(jti uuid.UUID, userID uuid.UUID, expiresAt time.Time) error {
+	_, err := r.db.Exec(`
+	  INSERT INTO revoked_tokens (jti, user_id, expires_at)
+	  VALUES ($1, $2, $3)
+	  ON CONFLICT (jti) DO NOTHING
+	`, jti, userID, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+	// expired tokens are rejected on their own, so their rows can go
+	if _, err := r.db.Exec(`DELETE FROM revoked_tokens WHERE expires_at < now()`); err != nil {
+		return fmt.Errorf("failed to prune revoked tokens: %w", err)
+	}
+	return nil
+}
+
+func (r *tokenRepository) IsAccessTokenRevoked(jti uuid.UUID) (bool, error) {
+	var revoked bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)`, jti).Scan(&revoked)
+	if err != nil {
+		return false, fmt.Errorf("failed to check revoked token: %w", err)
+	}
+	return revoked, nil
+}
+
+// RevokeAllForUser invalidates every access token the user was issued
+// before at and revokes all of their refresh tokens.
+func (r *tokenRepository) RevokeAllForUser(userID uuid.UUID, at time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE users SET tokens_valid_after = $1 WHERE id = $2`, at, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrUserNotFound
+	}
+	_, err = tx.Exec(`
+	  UPDATE refresh_tokens SET revoked_at = $1
+	  WHERE user_id = $2 AND revoked_at IS NULL
+	`, at, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit session revocation: %w", err)
+	}
+	return nil
+}
+
+func (r *tokenRepository) GetTokensValidAfter(userID uuid.UUID) (*time.Time, error) {
+	var after *time.Time
+	err := r.db.QueryRow(`SELECT tokens_valid_after FROM users WHERE id = $1`, userID).Scan(&after)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user session cutoff: %w", err)
+	}
+	return after, nil
+}
+
+const userTokenColumns = `id, user_id, purpose, token_hash, expires_at, used_at, created_at, email`
+
+func scanUserToken(row rowScanner, t *models.UserToken) error {
+	return row.Scan(&t.ID, &t.UserID, &t.Purpose, &t.TokenHash, &t.ExpiresAt, &t.UsedAt, &t.CreatedAt, &t.Email)
+}
+
+func (r *tokenRepository) CreateUserToken(t *models.UserToken) error {
+	t.ID = uuid.New()
+	t.CreatedAt = time.Now()
+	_, err := r.db.Exec(`
+	  INSERT INTO user_tokens (id, user_id, purpose, token_hash, expires_at, created_at, email)
+	  VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, t.ID, t.UserID, t.Purpose, t.TokenHash, t.ExpiresAt, t.CreatedAt, t.Email)
+	if err != nil {
+		return fmt.Errorf("failed to create user token: %w", err)
+	}
+	return nil
+}
+
+// GetUserToken returns an unused, unexpired token without using it up.
+func (r *tokenRepository) GetUserToken(purpose models.TokenPurpose, hash string) (*models.UserToken, error) {
+	var t models.UserToken
+	err := scanUserToken(r.db.QueryRow(`
+	  SELECT `+userTokenColumns+`
+	  FROM user_tokens
+	  WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > now()
+	`, hash, purpose), &t)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrInvalidUserToken
+		}
+		return nil, fmt.Errorf("failed to get user token: %w", err)
+	}
+	return &t, nil
+}
+
+// ConsumeUserToken marks an unused, unexpired token as used and returns it.
+// Any other outstanding tokens the user has for the same purpose are used up
+// too, so only the most recent mail ever works.
+func (r *tokenRepository) ConsumeUserToken(purpose models.TokenPurpose, hash string) (*models.UserToken, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var t models.UserToken
+	err = scanUserToken(tx.QueryRow(`
+	  UPDATE user_tokens SET used_at = now()
+	  WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > now()
+	  RETURNING `+userTokenColumns+`
+	`, hash, purpose), &t)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrInvalidUserToken
+		}
+		return nil, fmt.Errorf("failed to consume user token: %w", err)
+	}
+	_, err = tx.Exec(`
+	  UPDATE user_tokens SET used_at = now()
+	  WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL
+	`, t.UserID, purpose)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire user tokens: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit user token: %w", err)
+	}
+	return &t, nil
+}
+
+// LastUserTokenAt returns when the user was last issued a token for
+// purpose, or nil if never.
+func (r *tokenRepository) LastUserTokenAt(userID uuid.UUID, purpose models.TokenPurpose) (*time.Time, error) {
+	var at *time.Time
+	err := r.db.QueryRow(`
+	  SELECT max(created_at) FROM user_tokens WHERE user_id = $1 AND purpose = $2
+	`, userID, purpose).Scan(&at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last user token: %w", err)
+	}
+	return at, nil
+}
+
+const personalTokenColumns = `id, user_id, name, token_hash, prefix, scopes, expires_at, last_used_at, revoked_at, created_at`
+
+func scanPersonalToken(row rowScanner, t *models.PersonalAccessToken) error {
+	return row.Scan(&t.ID, &t.UserID, &t.Name, &t.TokenHash, &t.Prefix, pq.Array(&t.Scopes), &t.ExpiresAt, &t.LastUsedAt, &t.RevokedAt, &t.CreatedAt)
+}
+
+func (r *tokenRepository) CreatePersonalToken(t *models.PersonalAccessToken) error {
+	t.ID = uuid.New()
+	t.CreatedAt = time.Now()
+	_, err := r.db.Exec(`
+	  INSERT INTO personal_access_tokens (id, user_id, name, token_hash, prefix, scopes, expires_at, created_at)
+	  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, t.ID, t.UserID, t.Name, t.TokenHash, t.Prefix, pq.Array(t.Scopes), t.ExpiresAt, t.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return models.ErrPersonalTokenExists
+		}
+		return fmt.Errorf("failed to create personal access token: %w", err)
+	}
+	return nil
+}
+
+// GetPersonalTokensByUser lists the user's tokens that haven't been revoked.
+func (r *tokenRepository) GetPersonalTokensByUser(userID uuid.UUID) ([]models.PersonalAccessToken, error) {
+	rows, err := r.db.Query(`
+	  SELECT `+personalTokenColumns+`
+	  FROM personal_access_tokens
+	  WHERE user_id = $1 AND revoked_at IS NULL
+	  ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query personal access tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []models.PersonalAccessToken{}
+	for rows.Next() {
+		var t models.PersonalAccessToken
+		if err := scanPersonalToken(rows, &t); err != nil {
+			return nil, fmt.Errorf("failed to scan personal access token: %w", err)
+		}
+		tokens = append(tokens, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return tokens, nil
+}
+
+func (r *tokenRepository) GetPersonalTokenByHash(hash string) (*models.PersonalAccessToken, error) {
+	var t models.PersonalAccessToken
+	err := scanPersonalToken(r.db.QueryRow(`
+	  SELECT `+personalTokenColumns+` FROM personal_access_tokens WHERE token_hash = $1
+	`, hash), &t)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrPersonalTokenNotFound
+		}
+		return nil, fmt.Errorf("failed to get personal access token: %w", err)
+	}
+	return &t, nil
+}
+
+func (r *tokenRepository) RevokePersonalTokenForUser(userID uuid.UUID, id uuid.UUID) error {
+	res, err := r.db.Exec(`
+	  UPDATE personal_access_tokens SET revoked_at = now()
+	  WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke personal access token: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrPersonalTokenNotFound
+	}
+	return nil
+}
+
+// TouchPersonalToken records that a token was used. To keep busy scripts
+// from writing on every request it is only updated once a minute.
+func (r *tokenRepository) TouchPersonalToken(id uuid.UUID) error {
+	_, err := r.db.Exec(`
+	  UPDATE personal_access_tokens SET last_used_at = now()
+	  WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to update personal access token: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type TOTPRepository interface {
+	GetTOTPState(userID uuid.UUID) (*models.TOTPState, error)
+	SetPendingTOTPSecret(userID uuid.UUID, secret string) error
+	EnableTOTP(userID uuid.UUID, step int64, codeHashes []string) error
+	DisableTOTP(userID uuid.UUID) error
+	UseTOTPStep(userID uuid.UUID, step int64) (bool, error)
+	ReplaceRecoveryCodes(userID uuid.UUID, codeHashes []string) error
+	UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error)
+}
+
+type totpRepository struct {
+	db *sql.DB
+}
+
+func NewTOTPRepository(db *sql.DB) TOTPRepository {
+	return &totpRepository{db: db}
+}
+
+func (r *totpRepository) GetTOTPState(userID uuid.UUID) (*models.TOTPState, error) {
+	var st models.TOTPState
+	err := r.db.QueryRow(`
+	  SELECT totp_secret, totp_enabled_at, totp_last_step FROM users WHERE id = $1
+	`, userID).Scan(&st.Secret, &st.EnabledAt, &st.LastStep)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get totp state: %w", err)
+	}
+	return &st, nil
+}
+
+// SetPendingTOTPSecret starts (or restarts) enrollment. It does nothing to
+// users who already have 2FA enabled.
+func (r *totpRepository) SetPendingTOTPSecret(userID uuid.UUID, secret string) error {
+	res, err := r.db.Exec(`
+	  UPDATE users SET totp_secret = $1, totp_last_step = NULL, updated_at = now()
+	  WHERE id = $2 AND totp_enabled_at IS NULL
+	`, secret, userID)
+	if err != nil {
+		return fmt.Errorf("failed to store totp secret: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrTOTPAlreadyEnabled
+	}
+	return nil
+}
+
+// EnableTOTP finishes enrollment, recording the step of the confirming code
+// and storing the first set of recovery codes.
+func (r *totpRepository) EnableTOTP(userID uuid.UUID, step int64, codeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+	  UPDATE users SET totp_enabled_at = now(), totp_last_step = $1, updated_at = now()
+	  WHERE id = $2 AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL
+	`, step, userID)
+	if err != nil {
+		return fmt.Errorf("failed to enable totp: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrTOTPAlreadyEnabled
+	}
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit totp enrollment: %w", err)
+	}
+	return nil
+}
+
+func (r *totpRepository) DisableTOTP(userID uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+	  UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL, updated_at = now()
+	  WHERE id = $1
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to disable totp: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit totp removal: %w", err)
+	}
+	return nil
+}
+
+// UseTOTPStep records that the code for step was used. It returns false if
+// that step or a later one was used already, i.e. the code is a replay.
+func (r *totpRepository) UseTOTPStep(userID uuid.UUID, step int64) (bool, error) {
+	res, err := r.db.Exec(`
+	  UPDATE users SET totp_last_step = $1
+	  WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)
+	`, step, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to record totp step: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return affected == 1, nil
+}
+
+func (r *totpRepository) ReplaceRecoveryCodes(userID uuid.UUID, codeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit recovery codes: %w", err)
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(db execer, userID uuid.UUID, codeHashes []string) error {
+	if _, err := db.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	_, err := db.Exec(`
+	  INSERT INTO recovery_codes (user_id, code_hash)
+	  SELECT $1, unnest($2::text[])
+	`, userID, pq.Array(codeHashes))
+	if err != nil {
+		return fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used, returning false if
+// there was none.
+func (r *totpRepository) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	res, err := r.db.Exec(`
+	  UPDATE recovery_codes SET used_at = now()
+	  WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return affected == 1, nil
+}
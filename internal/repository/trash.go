@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/google/uuid"
+)
+
+// GetTrashByUser lists the user's trashed todos in their personal space or
+// the given workspace, most recently deleted first. Subtasks trashed along
+// with their parent are left out; they come back when the parent is
+// restored.
+func (r *todoRepository) GetTrashByUser(userID uuid.UUID, workspaceID *uuid.UUID) ([]models.Todo, error) {
+	todos, err := r.queryTodos(`
+	  SELECT `+todoColumns+`
+	  FROM todos
+	  WHERE user_id = $1 AND workspace_id IS NOT DISTINCT FROM $2 AND deleted_at IS NOT NULL
+	    AND NOT EXISTS (
+	      SELECT 1 FROM todos p
+	      WHERE p.id = todos.parent_id AND p.deleted_at IS NOT NULL
+	    )
+	  ORDER BY deleted_at DESC, id DESC
+	`, userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if todos == nil {
+		todos = []models.Todo{}
+	}
+	return todos, nil
+}
+
+// RestoreTodoForUser takes a todo out of the trash together with the
+// subtasks that were trashed with it.
+func (r *todoRepository) RestoreTodoForUser(userID uuid.UUID, workspaceID *uuid.UUID, id uuid.UUID) (*models.Todo, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var deletedAt time.Time
+	var parentDeleted bool
+	err = tx.QueryRow(`
+	  SELECT t.deleted_at, p.deleted_at IS NOT NULL
+	  FROM todos t
+	  LEFT JOIN todos p ON p.id = t.parent_id
+	  WHERE t.id = $1 AND t.user_id = $2 AND t.workspace_id IS NOT DISTINCT FROM $3 AND t.deleted_at IS NOT NULL
+	  FOR UPDATE OF t
+	`, id, userID, workspaceID).Scan(&deletedAt, &parentDeleted)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrTodoNotFound
+		}
+		return nil, fmt.Errorf("failed to get trashed todo: %w", err)
+	}
+	if parentDeleted {
+		return nil, models.ErrParentInTrash
+	}
+
+	now := time.Now()
+	_, err = tx.Exec(`
+	  UPDATE todos SET deleted_at = NULL, updated_at = $1
+	  WHERE id = $2 OR (parent_id = $2 AND deleted_at = $3)
+	`, now, id, deletedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore todo: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit todo restore: %w", err)
+	}
+	return r.GetTodoByIDForUser(userID, id)
+}
+
+// PurgeTodoForUser permanently deletes a todo that is already in the trash.
+func (r *todoRepository) PurgeTodoForUser(userID uuid.UUID, workspaceID *uuid.UUID, id uuid.UUID) error {
+	res, err := r.db.Exec(`
+	  DELETE FROM todos
+	  WHERE id = $1 AND user_id = $2 AND workspace_id IS NOT DISTINCT FROM $3 AND deleted_at IS NOT NULL
+	`, id, userID, workspaceID)
+	if err != nil {
+		return fmt.Errorf("failed to purge user todo: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrTodoNotFound
+	}
+	return nil
+}
+
+// EmptyTrashForUser permanently deletes everything in the user's trash for
+// their personal space or the given workspace and returns how many todos
+// were removed.
+func (r *todoRepository) EmptyTrashForUser(userID uuid.UUID, workspaceID *uuid.UUID) (int64, error) {
+	res, err := r.db.Exec(`
+	  DELETE FROM todos WHERE user_id = $1 AND workspace_id IS NOT DISTINCT FROM $2 AND deleted_at IS NOT NULL
+	`, userID, workspaceID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to empty user trash: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// PurgeTrashedBefore permanently deletes todos of every user that were
+// trashed before cutoff.
+func (r *todoRepository) PurgeTrashedBefore(cutoff time.Time) (int64, error) {
+	res, err := r.db.Exec(`DELETE FROM todos WHERE deleted_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge trash: %w", err)
+	}
+	return res.RowsAffected()
+}
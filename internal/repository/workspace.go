@@ -0,0 +1,414 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/google/uuid"
+)
+
+type WorkspaceRepository interface {
+	CreateWorkspace(workspace *models.Workspace) error
+	GetWorkspacesByUser(userID uuid.UUID) ([]models.Workspace, error)
+	GetWorkspaceForMember(userID uuid.UUID, id uuid.UUID) (*models.Workspace, error)
+	UpdateWorkspace(id uuid.UUID, name string) error
+	DeleteWorkspace(id uuid.UUID) error
+	GetMemberRole(userID uuid.UUID, id uuid.UUID) (models.WorkspaceRole, error)
+	GetMembers(id uuid.UUID) ([]models.WorkspaceMembership, error)
+	AddMember(member *models.WorkspaceMembership) error
+	SetMemberRole(id uuid.UUID, userID uuid.UUID, role models.WorkspaceRole) error
+	RemoveMember(id uuid.UUID, userID uuid.UUID) error
+}
+
+type workspaceRepository struct {
+	db *sql.DB
+}
+
+func NewWorkspaceRepository(db *sql.DB) WorkspaceRepository {
+	return &workspaceRepository{db: db}
+}
+
+// workspaceListQuery selects workspaces as w with their member count and
+// the role of the member $1.
+const workspaceListQuery = `
+	  SELECT w.id, w.name, w.created_by, w.created_at, w.updated_at,
+	    (SELECT count(*) FROM workspace_members c WHERE c.workspace_id = w.id), m.role
+	  FROM workspaces w
+	  JOIN workspace_members m ON m.workspace_id = w.id AND m.user_id = $1
+`
+
+func scanWorkspace(row rowScanner, w *models.Workspace) error {
+	return row.Scan(&w.ID, &w.Name, &w.CreatedBy, &w.CreatedAt, &w.UpdatedAt, &w.MemberCount, &w.Role)
+}
+
+// CreateWorkspace stores the workspace and makes its creator the first
+// owner.
+func (r *workspaceRepository) CreateWorkspace(workspace *models.Workspace) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	workspace.ID = uuid.New()
+	workspace.CreatedAt = time.Now()
+	workspace.UpdatedAt = workspace.CreatedAt
+	_, err = tx.Exec(`
+	  INSERT INTO workspaces (id, name, created_by, created_at, updated_at)
+	  VALUES ($1, $2, $3, $4, $5)
+	`, workspace.ID, workspace.Name, workspace.CreatedBy, workspace.CreatedAt, workspace.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+	_, err = tx.Exec(`
+	  INSERT INTO workspace_members (workspace_id, user_id, role, created_at)
+	  VALUES ($1, $2, $3, $4)
+	`, workspace.ID, workspace.CreatedBy, models.WorkspaceOwner, workspace.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add workspace owner: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit workspace: %w", err)
+	}
+	workspace.MemberCount = 1
+	workspace.Role = models.WorkspaceOwner
+	return nil
+}
+
+func (r *workspaceRepository) GetWorkspacesByUser(userID uuid.UUID) ([]models.Workspace, error) {
+	rows, err := r.db.Query(workspaceListQuery+`ORDER BY lower(w.name), w.id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workspaces: %w", err)
+	}
+	defer rows.Close()
+
+	workspaces := []models.Workspace{}
+	for rows.Next() {
+		var w models.Workspace
+		if err := scanWorkspace(rows, &w); err != nil {
+			return nil, fmt.Errorf("failed to scan workspace: %w", err)
+		}
+		workspaces = append(workspaces, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return workspaces, nil
+}
+
+// GetWorkspaceForMember returns the workspace if the user is one of its
+// members, and ErrWorkspaceNotFound otherwise.
+func (r *workspaceRepository) GetWorkspaceForMember(userID uuid.UUID, id uuid.UUID) (*models.Workspace, error) {
+	var w models.Workspace
+	if err := scanWorkspace(r.db.QueryRow(workspaceListQuery+`WHERE w.id = $2`, userID, id), &w); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrWorkspaceNotFound
+		}
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+	return &w, nil
+}
+
+func (r *workspaceRepository) UpdateWorkspace(id uuid.UUID, name string) error {
+	res, err := r.db.Exec(`UPDATE workspaces SET name = $1, updated_at = now() WHERE id = $2`, name, id)
+	if err != nil {
+		return fmt.Errorf("failed to update workspace: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrWorkspaceNotFound
+	}
+	return nil
+}
+
+// DeleteWorkspace removes the workspace along with its members, todos and
+// projects.
+func (r *workspaceRepository) DeleteWorkspace(id uuid.UUID) error {
+	res, err := r.db.Exec(`DELETE FROM workspaces WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete workspace: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrWorkspaceNotFound
+	}
+	return nil
+}
+
+// GetMemberRole returns the user's role in the workspace. Workspaces the
+// user isn't a member of are ErrWorkspaceNotFound, so their existence
+// isn't revealed.
+func (r *workspaceRepository) GetMemberRole(userID uuid.UUID, id uuid.UUID) (models.WorkspaceRole, error) {
+	var role models.WorkspaceRole
+	err := r.db.QueryRow(`
+	  SELECT role FROM workspace_members WHERE workspace_id = $1 AND user_id = $2
+	`, id, userID).Scan(&role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", models.ErrWorkspaceNotFound
+		}
+		return "", fmt.Errorf("failed to get workspace role: %w", err)
+	}
+	return role, nil
+}
+
+func (r *workspaceRepository) GetMembers(id uuid.UUID) ([]models.WorkspaceMembership, error) {
+	rows, err := r.db.Query(`
+	  SELECT m.workspace_id, m.user_id, m.role, m.created_at, u.name, u.email
+	  FROM workspace_members m
+	  JOIN users u ON u.id = m.user_id
+	  WHERE m.workspace_id = $1
+	  ORDER BY m.created_at, m.user_id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workspace members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []models.WorkspaceMembership{}
+	for rows.Next() {
+		var m models.WorkspaceMembership
+		if err := rows.Scan(&m.WorkspaceID, &m.UserID, &m.Role, &m.CreatedAt, &m.Name, &m.Email); err != nil {
+			return nil, fmt.Errorf("failed to scan workspace member: %w", err)
+		}
+		members = append(members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return members, nil
+}
+
+func (r *workspaceRepository) AddMember(member *models.WorkspaceMembership) error {
+	member.CreatedAt = time.Now()
+	_, err := r.db.Exec(`
+	  INSERT INTO workspace_members (workspace_id, user_id, role, created_at)
+	  VALUES ($1, $2, $3, $4)
+	`, member.WorkspaceID, member.UserID, member.Role, member.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return models.ErrAlreadyMember
+		}
+		if isForeignKeyViolation(err) {
+			return models.ErrWorkspaceNotFound
+		}
+		return fmt.Errorf("failed to add workspace member: %w", err)
+	}
+	return nil
+}
+
+// checkNotLastOwner fails with ErrLastOwner if the user is an owner of the
+// workspace and taking that away would leave it without one. The
+// workspace row stays locked until the transaction ends, so two owners
+// can't step down at the same time.
+func checkNotLastOwner(tx *sql.Tx, id uuid.UUID, userID uuid.UUID) error {
+	var role models.WorkspaceRole
+	var others bool
+	err := tx.QueryRow(`
+	  SELECT m.role, EXISTS (
+	    SELECT 1 FROM workspace_members o
+	    WHERE o.workspace_id = w.id AND o.user_id <> m.user_id AND o.role = $3
+	  )
+	  FROM workspaces w
+	  JOIN workspace_members m ON m.workspace_id = w.id AND m.user_id = $2
+	  WHERE w.id = $1
+	  FOR UPDATE OF w
+	`, id, userID, models.WorkspaceOwner).Scan(&role, &others)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.ErrMemberNotFound
+		}
+		return fmt.Errorf("failed to count workspace owners: %w", err)
+	}
+	if role == models.WorkspaceOwner && !others {
+		return models.ErrLastOwner
+	}
+	return nil
+}
+
+// SetMemberRole changes a member's role, refusing to demote the last owner.
+func (r *workspaceRepository) SetMemberRole(id uuid.UUID, userID uuid.UUID, role models.WorkspaceRole) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if role != models.WorkspaceOwner {
+		if err := checkNotLastOwner(tx, id, userID); err != nil {
+			return err
+		}
+	}
+	res, err := tx.Exec(`
+	  UPDATE workspace_members SET role = $1 WHERE workspace_id = $2 AND user_id = $3
+	`, role, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update workspace member: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrMemberNotFound
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit workspace member: %w", err)
+	}
+	return nil
+}
+
+// RemoveMember takes the user out of the workspace, refusing to remove the
+// last owner. Their todos stay in the workspace.
+func (r *workspaceRepository) RemoveMember(id uuid.UUID, userID uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkNotLastOwner(tx, id, userID); err != nil {
+		return err
+	}
+	res, err := tx.Exec(`DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove workspace member: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrMemberNotFound
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit workspace member removal: %w", err)
+	}
+	return nil
+}
+
+// leaveWorkspaces takes the user out of all their workspaces before their
+// account goes away. Workspaces nobody else is in are deleted; one where
+// they are the only owner but others remain fails with ErrLastOwner, so
+// ownership has to be handed over first. The todos and projects they
+// created in the remaining workspaces are handed to another owner, since
+// they would otherwise go with the account.
+func leaveWorkspaces(tx *sql.Tx, userID uuid.UUID) error {
+	var soleOwner bool
+	err := tx.QueryRow(`
+	  SELECT EXISTS (
+	    SELECT 1 FROM workspace_members m
+	    WHERE m.user_id = $1 AND m.role = $2
+	      AND EXISTS (SELECT 1 FROM workspace_members o WHERE o.workspace_id = m.workspace_id AND o.user_id <> $1)
+	      AND NOT EXISTS (
+	        SELECT 1 FROM workspace_members o
+	        WHERE o.workspace_id = m.workspace_id AND o.user_id <> $1 AND o.role = $2
+	      )
+	  )
+	`, userID, models.WorkspaceOwner).Scan(&soleOwner)
+	if err != nil {
+		return fmt.Errorf("failed to check workspace ownership: %w", err)
+	}
+	if soleOwner {
+		return models.ErrLastOwner
+	}
+	_, err = tx.Exec(`
+	  DELETE FROM workspaces w
+	  WHERE EXISTS (SELECT 1 FROM workspace_members m WHERE m.workspace_id = w.id AND m.user_id = $1)
+	    AND NOT EXISTS (SELECT 1 FROM workspace_members m WHERE m.workspace_id = w.id AND m.user_id <> $1)
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete workspaces: %w", err)
+	}
+	if err := handOverWorkspaceItems(tx, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM workspace_members WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to leave workspaces: %w", err)
+	}
+	return nil
+}
+
+// handOverWorkspaceItems moves the todos and projects userID created in
+// their workspaces to the longest-standing other owner of each workspace.
+// Tags belong to a todo's creator, so the new owner gets tags of the same
+// names and the todos are retagged with those.
+func handOverWorkspaceItems(tx *sql.Tx, userID uuid.UUID) error {
+	rows, err := tx.Query(`
+	  SELECT DISTINCT ON (m.workspace_id) m.workspace_id, o.user_id
+	  FROM workspace_members m
+	  JOIN workspace_members o ON o.workspace_id = m.workspace_id AND o.user_id <> $1 AND o.role = $2
+	  WHERE m.user_id = $1
+	  ORDER BY m.workspace_id, o.created_at, o.user_id
+	`, userID, models.WorkspaceOwner)
+	if err != nil {
+		return fmt.Errorf("failed to find new workspace owners: %w", err)
+	}
+	newOwners := map[uuid.UUID]uuid.UUID{}
+	for rows.Next() {
+		var workspaceID, ownerID uuid.UUID
+		if err := rows.Scan(&workspaceID, &ownerID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan new workspace owner: %w", err)
+		}
+		newOwners[workspaceID] = ownerID
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("row iteration error: %w", err)
+	}
+	rows.Close()
+
+	for workspaceID, ownerID := range newOwners {
+		_, err := tx.Exec(`
+		  INSERT INTO tags (user_id, name, color)
+		  SELECT DISTINCT ON (lower(g.name)) $3, g.name, g.color
+		  FROM todo_tags tt
+		  JOIN tags g ON g.id = tt.tag_id AND g.user_id = $1
+		  JOIN todos t ON t.id = tt.todo_id AND t.workspace_id = $2 AND t.user_id = $1
+		  ORDER BY lower(g.name)
+		  ON CONFLICT (user_id, lower(name)) DO NOTHING
+		`, userID, workspaceID, ownerID)
+		if err != nil {
+			return fmt.Errorf("failed to copy tags: %w", err)
+		}
+		_, err = tx.Exec(`
+		  INSERT INTO todo_tags (todo_id, tag_id)
+		  SELECT tt.todo_id, n.id
+		  FROM todo_tags tt
+		  JOIN tags g ON g.id = tt.tag_id AND g.user_id = $1
+		  JOIN todos t ON t.id = tt.todo_id AND t.workspace_id = $2 AND t.user_id = $1
+		  JOIN tags n ON n.user_id = $3 AND lower(n.name) = lower(g.name)
+		  ON CONFLICT DO NOTHING
+		`, userID, workspaceID, ownerID)
+		if err != nil {
+			return fmt.Errorf("failed to retag todos: %w", err)
+		}
+		_, err = tx.Exec(`
+		  DELETE FROM todo_tags tt
+		  USING tags g, todos t
+		  WHERE g.id = tt.tag_id AND g.user_id = $1
+		    AND t.id = tt.todo_id AND t.workspace_id = $2 AND t.user_id = $1
+		`, userID, workspaceID)
+		if err != nil {
+			return fmt.Errorf("failed to untag todos: %w", err)
+		}
+		_, err = tx.Exec(`UPDATE todos SET user_id = $3 WHERE user_id = $1 AND workspace_id = $2`, userID, workspaceID, ownerID)
+		if err != nil {
+			return fmt.Errorf("failed to hand over todos: %w", err)
+		}
+		_, err = tx.Exec(`UPDATE projects SET user_id = $3 WHERE user_id = $1 AND workspace_id = $2`, userID, workspaceID, ownerID)
+		if err != nil {
+			return fmt.Errorf("failed to hand over projects: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/danieldzansi/todo-api/internal/dbtest"
+	models "github.com/danieldzansi/todo-api/internal/model"
+)
+
+func TestDeleteUserKeepsWorkspaceTodos(t *testing.T) {
+	db := dbtest.Open(t)
+	users := NewUserRepository(db)
+	workspaces := NewWorkspaceRepository(db)
+	todos := NewTodoRepository(db)
+
+	owner := createTestUser(t, db, "Owner")
+	member := createTestUser(t, db, "Member")
+	workspace := &models.Workspace{Name: "Team", CreatedBy: &owner.ID}
+	if err := workspaces.CreateWorkspace(workspace); err != nil {
+		t.Fatal(err)
+	}
+	err := workspaces.AddMember(&models.WorkspaceMembership{WorkspaceID: workspace.ID, UserID: member.ID, Role: models.WorkspaceMember})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	todo := &models.Todo{Title: "Shared work", UserID: member.ID, WorkspaceID: &workspace.ID, Tags: []string{"Urgent"}}
+	if err := todos.CreateTodo(todo); err != nil {
+		t.Fatal(err)
+	}
+	subtask := &models.Todo{Title: "Step one", UserID: member.ID, WorkspaceID: &workspace.ID, ParentID: &todo.ID}
+	if err := todos.CreateTodo(subtask); err != nil {
+		t.Fatal(err)
+	}
+	personal := &models.Todo{Title: "Mine", UserID: member.ID}
+	if err := todos.CreateTodo(personal); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := users.DeleteUser(member.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []*models.Todo{todo, subtask} {
+		got, err := todos.GetTodoByIDForUser(owner.ID, want.ID)
+		if err != nil {
+			t.Fatalf("%q after deleting its creator: %v", want.Title, err)
+		}
+		if got.UserID != owner.ID {
+			t.Errorf("%q belongs to %s, want the workspace owner %s", want.Title, got.UserID, owner.ID)
+		}
+	}
+	got, err := todos.GetTodoByIDForUser(owner.ID, todo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Tags) != 1 || got.Tags[0] != "Urgent" {
+		t.Errorf("tags = %v, want [Urgent]", got.Tags)
+	}
+	if _, err := todos.GetTodoByID(personal.ID); err != models.ErrTodoNotFound {
+		t.Errorf("personal todo: err = %v, want ErrTodoNotFound", err)
+	}
+}
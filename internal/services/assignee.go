@@ -0,0 +1,77 @@
+package services
+
+import (
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/google/uuid"
+)
+
+// checkAssignee makes sure assigneeID can see the existing todo id.
+func (s *todoService) checkAssignee(assigneeID uuid.UUID, id uuid.UUID) error {
+	_, _, err := s.repo.GetTodoAccess(assigneeID, id)
+	if err == models.ErrTodoNotFound {
+		return models.ErrInvalidAssignee
+	}
+	return err
+}
+
+// checkNewAssignee does the same for a todo that is about to be created:
+// besides its owner, only members of its workspace or users its project
+// is shared with will be able to see it.
+func (s *todoService) checkNewAssignee(assigneeID *uuid.UUID, todo *models.Todo) error {
+	if assigneeID == nil || *assigneeID == todo.UserID {
+		return nil
+	}
+	var err error
+	switch {
+	case todo.ProjectID != nil:
+		_, _, err = s.projects.GetProjectAccess(*assigneeID, *todo.ProjectID)
+	case todo.WorkspaceID != nil:
+		_, err = s.workspaces.GetMemberRole(*assigneeID, *todo.WorkspaceID)
+	default:
+		return models.ErrInvalidAssignee
+	}
+	if err == models.ErrProjectNotFound || err == models.ErrWorkspaceNotFound {
+		return models.ErrInvalidAssignee
+	}
+	return err
+}
+
+// AssignTodoForUser hands the todo to assigneeID, who must be able to see
+// it. Editors can assign.
+func (s *todoService) AssignTodoForUser(userID uuid.UUID, id uuid.UUID, assigneeID uuid.UUID) (*models.Todo, error) {
+	ownerID, err := s.authorize(userID, id, models.ShareEditor)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAssignee(assigneeID, id); err != nil {
+		return nil, err
+	}
+	return s.repo.AssignTodo(ownerID, id, &assigneeID)
+}
+
+// UnassignTodoForUser clears the todo's assignee. Editors can unassign
+// anyone; the assignee can always hand the todo back.
+func (s *todoService) UnassignTodoForUser(userID uuid.UUID, id uuid.UUID) (*models.Todo, error) {
+	ownerID, role, err := s.repo.GetTodoAccess(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !role.Allows(models.ShareEditor) {
+		todo, err := s.repo.GetTodoByIDForUser(ownerID, id)
+		if err != nil {
+			return nil, err
+		}
+		if todo.AssigneeID == nil || *todo.AssigneeID != userID {
+			return nil, models.ErrForbidden
+		}
+	}
+	return s.repo.AssignTodo(ownerID, id, nil)
+}
+
+func (s *todoService) GetAssignedTodos(userID uuid.UUID, workspaceID *uuid.UUID) ([]models.Todo, error) {
+	return s.repo.GetAssignedTodos(userID, workspaceID)
+}
+
+func (s *todoService) GetWorkload(userID uuid.UUID, workspaceID *uuid.UUID) ([]models.Workload, error) {
+	return s.repo.GetWorkload(userID, workspaceID)
+}
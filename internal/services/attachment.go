@@ -0,0 +1,305 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/danieldzansi/todo-api/internal/blob"
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// maxFilenameLength caps stored attachment names, in characters.
+const maxFilenameLength = 255
+
+type AttachmentService interface {
+	UploadAttachment(userID uuid.UUID, todoID uuid.UUID, upload *models.AttachmentUpload) (*models.Attachment, error)
+	GetAttachments(userID uuid.UUID, todoID uuid.UUID) ([]models.Attachment, error)
+	OpenAttachment(userID uuid.UUID, todoID uuid.UUID, id uuid.UUID) (*models.Attachment, io.ReadCloser, error)
+	DeleteAttachment(userID uuid.UUID, todoID uuid.UUID, id uuid.UUID) error
+	MaxSize() int64
+}
+
+type attachmentService struct {
+	repo    repository.AttachmentRepository
+	todos   repository.TodoRepository
+	store   blob.BlobStore
+	maxSize int64
+	types   map[string]bool
+}
+
+// NewAttachmentService accepts uploads of up to maxSize bytes whose sniffed
+// media type is one of types.
+func NewAttachmentService(r repository.AttachmentRepository, todos repository.TodoRepository, store blob.BlobStore, maxSize int64, types []string) AttachmentService {
+	allowed := make(map[string]bool, len(types))
+	for _, t := range types {
+		allowed[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+	return &attachmentService{repo: r, todos: todos, store: store, maxSize: maxSize, types: allowed}
+}
+
+func (s *attachmentService) MaxSize() int64 {
+	return s.maxSize
+}
+
+// authorize checks that the user holds at least need on the todo and
+// returns their role.
+func (s *attachmentService) authorize(userID uuid.UUID, todoID uuid.UUID, need models.ShareRole) (models.ShareRole, error) {
+	_, role, err := s.todos.GetTodoAccess(userID, todoID)
+	if err != nil {
+		return "", err
+	}
+	if !role.Allows(need) {
+		return "", models.ErrForbidden
+	}
+	return role, nil
+}
+
+// attachmentFilename keeps the last path element of the client's file
+// name, without control characters.
+func attachmentFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name))
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	if utf8.RuneCountInString(name) > maxFilenameLength {
+		name = string([]rune(name)[:maxFilenameLength])
+	}
+	return name
+}
+
+func validSHA256(s string) bool {
+	b, err := hex.DecodeString(s)
+	return err == nil && len(b) == sha256.Size
+}
+
+// inspect reads the upload once to sniff its media type and compute its
+// digest, then rewinds it.
+func inspect(content io.ReadSeeker, size int64) (contentType string, digest string, err error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", "", fmt.Errorf("failed to read upload: %w", err)
+	}
+	head = head[:n]
+	h := sha256.New()
+	h.Write(head)
+	rest, err := io.Copy(h, content)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read upload: %w", err)
+	}
+	if int64(n)+rest != size {
+		return "", "", fmt.Errorf("upload is %d bytes, expected %d", int64(n)+rest, size)
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", "", fmt.Errorf("failed to rewind upload: %w", err)
+	}
+	return http.DetectContentType(head), hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// UploadAttachment stores a file on the todo; editors may attach files.
+// The media type is sniffed from the content, and when the client sends a
+// SHA-256 the content has to match it. The blob store checks the digest
+// again while writing.
+func (s *attachmentService) UploadAttachment(userID uuid.UUID, todoID uuid.UUID, upload *models.AttachmentUpload) (*models.Attachment, error) {
+	if _, err := s.authorize(userID, todoID, models.ShareEditor); err != nil {
+		return nil, err
+	}
+	if upload.Size <= 0 {
+		return nil, models.ErrEmptyAttachment
+	}
+	if upload.Size > s.maxSize {
+		return nil, models.ErrAttachmentTooLarge
+	}
+	want := strings.ToLower(strings.TrimSpace(upload.SHA256))
+	if want != "" && !validSHA256(want) {
+		return nil, models.ErrInvalidChecksum
+	}
+	contentType, digest, err := inspect(upload.Content, upload.Size)
+	if err != nil {
+		return nil, err
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || !s.types[mediaType] {
+		return nil, models.ErrUnsupportedAttachmentType
+	}
+	if want != "" && want != digest {
+		return nil, models.ErrChecksumMismatch
+	}
+
+	attachment := &models.Attachment{
+		TodoID:      todoID,
+		UploadedBy:  &userID,
+		Filename:    attachmentFilename(upload.Filename),
+		ContentType: contentType,
+		Size:        upload.Size,
+		SHA256:      digest,
+		StorageKey:  fmt.Sprintf("todos/%s/%s", todoID, uuid.New()),
+	}
+	err = s.store.Put(attachment.StorageKey, upload.Content, blob.Object{Size: upload.Size, ContentType: contentType, SHA256: digest})
+	if err != nil {
+		if err == blob.ErrChecksumMismatch {
+			return nil, models.ErrChecksumMismatch
+		}
+		return nil, err
+	}
+	if err := s.repo.CreateAttachment(attachment); err != nil {
+		if delErr := s.store.Delete(attachment.StorageKey); delErr != nil {
+			log.Printf("Failed to delete blob %s after failed upload: %v", attachment.StorageKey, delErr)
+		}
+		return nil, err
+	}
+	return attachment, nil
+}
+
+func (s *attachmentService) GetAttachments(userID uuid.UUID, todoID uuid.UUID) ([]models.Attachment, error) {
+	if _, err := s.authorize(userID, todoID, models.ShareViewer); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAttachments(todoID)
+}
+
+// OpenAttachment returns the attachment with a reader over its content.
+// The reader checks the content against the stored digest and fails at the
+// end instead of returning io.EOF when it doesn't match, so a corrupted
+// blob never downloads as if it were complete.
+func (s *attachmentService) OpenAttachment(userID uuid.UUID, todoID uuid.UUID, id uuid.UUID) (*models.Attachment, io.ReadCloser, error) {
+	if _, err := s.authorize(userID, todoID, models.ShareViewer); err != nil {
+		return nil, nil, err
+	}
+	attachment, err := s.repo.GetAttachment(todoID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := s.store.Get(attachment.StorageKey)
+	if err != nil {
+		if err == blob.ErrNotFound {
+			log.Printf("Blob %s of attachment %s is missing", attachment.StorageKey, attachment.ID)
+		}
+		return nil, nil, err
+	}
+	return attachment, &verifyingReader{ReadCloser: content, attachment: attachment, h: sha256.New()}, nil
+}
+
+// DeleteAttachment removes an attachment. Editors can delete the files
+// they uploaded and the todo's owner can delete any. The blob itself is
+// removed by the BlobSweeper.
+func (s *attachmentService) DeleteAttachment(userID uuid.UUID, todoID uuid.UUID, id uuid.UUID) error {
+	role, err := s.authorize(userID, todoID, models.ShareEditor)
+	if err != nil {
+		return err
+	}
+	attachment, err := s.repo.GetAttachment(todoID, id)
+	if err != nil {
+		return err
+	}
+	isUploader := attachment.UploadedBy != nil && *attachment.UploadedBy == userID
+	if !isUploader && role != models.ShareOwner {
+		return models.ErrForbidden
+	}
+	return s.repo.DeleteAttachment(todoID, id)
+}
+
+// verifyingReader hashes an attachment's content as it is read.
+type verifyingReader struct {
+	io.ReadCloser
+	attachment *models.Attachment
+	h          hash.Hash
+	n          int64
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.h.Write(p[:n])
+	r.n += int64(n)
+	if err == io.EOF && (r.n != r.attachment.Size || hex.EncodeToString(r.h.Sum(nil)) != r.attachment.SHA256) {
+		log.Printf("Attachment %s failed checksum verification", r.attachment.ID)
+		return n, models.ErrChecksumMismatch
+	}
+	return n, err
+}
+
+// BlobSweeper periodically deletes the blobs of removed attachments from
+// the store. The database queues them whenever attachment rows go away,
+// including when their todo is purged from the trash.
+type BlobSweeper struct {
+	repo     repository.AttachmentRepository
+	store    blob.BlobStore
+	interval time.Duration
+	done     chan struct{}
+}
+
+func NewBlobSweeper(repo repository.AttachmentRepository, store blob.BlobStore, interval time.Duration) *BlobSweeper {
+	return &BlobSweeper{repo: repo, store: store, interval: interval, done: make(chan struct{})}
+}
+
+// Start runs a sweep right away and then once per interval until Stop is
+// called.
+func (p *BlobSweeper) Start() {
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			p.sweep()
+			select {
+			case <-ticker.C:
+			case <-p.done:
+				return
+			}
+		}
+	}()
+}
+
+func (p *BlobSweeper) Stop() {
+	close(p.done)
+}
+
+// sweepBatch is how many queued blobs one query fetches.
+const sweepBatch = 100
+
+func (p *BlobSweeper) sweep() {
+	deleted := 0
+	defer func() {
+		if deleted > 0 {
+			log.Printf("Deleted %d attachment blobs", deleted)
+		}
+	}()
+	for {
+		keys, err := p.repo.GetQueuedBlobDeletions(sweepBatch)
+		if err != nil {
+			log.Println("Failed to list blobs to delete:", err)
+			return
+		}
+		for _, key := range keys {
+			if err := p.store.Delete(key); err != nil {
+				log.Printf("Failed to delete blob %s: %v", key, err)
+				return
+			}
+			if err := p.repo.ClearBlobDeletion(key); err != nil {
+				log.Println("Failed to clear blob deletion:", err)
+				return
+			}
+			deleted++
+		}
+		if len(keys) < sweepBatch {
+			return
+		}
+	}
+}
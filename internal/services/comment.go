@@ -0,0 +1,221 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/danieldzansi/todo-api/internal/mailer"
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// maxMentions caps how many users one comment can notify.
+const maxMentions = 20
+
+// mentionPattern finds @mentions, written as @ followed by the user's
+// email address: "thanks @ana@example.com".
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w.+-])@([\w.+-]+@[\w-]+(?:\.[\w-]+)+)`)
+
+type CommentService interface {
+	CreateComment(userID uuid.UUID, todoID uuid.UUID, req *models.CreateCommentRequest) (*models.Comment, error)
+	GetComments(userID uuid.UUID, todoID uuid.UUID, page models.PageRequest) (*models.CommentPage, error)
+	UpdateComment(userID uuid.UUID, todoID uuid.UUID, id uuid.UUID, req *models.UpdateCommentRequest) (*models.Comment, error)
+	DeleteComment(userID uuid.UUID, todoID uuid.UUID, id uuid.UUID) error
+}
+
+type commentService struct {
+	repo  repository.CommentRepository
+	todos repository.TodoRepository
+	users repository.UserRepository
+	mail  mailer.Mailer
+}
+
+func NewCommentService(r repository.CommentRepository, todos repository.TodoRepository, users repository.UserRepository, mail mailer.Mailer) CommentService {
+	return &commentService{repo: r, todos: todos, users: users, mail: mail}
+}
+
+func commentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > models.MaxCommentLength {
+		return "", models.ErrInvalidComment
+	}
+	return body, nil
+}
+
+// resolveMentions looks up the users mentioned in body. Unknown addresses
+// and users who can't see the todo are skipped, so a mention never reveals
+// who has an account.
+func (s *commentService) resolveMentions(todoID uuid.UUID, body string) ([]models.User, error) {
+	seen := map[string]bool{}
+	var users []models.User
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		email := strings.ToLower(m[1])
+		if seen[email] {
+			continue
+		}
+		seen[email] = true
+		if len(seen) > maxMentions {
+			break
+		}
+		user, err := s.users.GetUserByEmail(email)
+		if err == models.ErrUserNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, _, err := s.todos.GetTodoAccess(user.ID, todoID); err != nil {
+			if err == models.ErrTodoNotFound {
+				continue
+			}
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	return users, nil
+}
+
+func userIDs(users []models.User) []uuid.UUID {
+	ids := make([]uuid.UUID, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	return ids
+}
+
+// notifyMentions mails the mentioned users, leaving out the author and
+// anyone in skip.
+func (s *commentService) notifyMentions(author uuid.UUID, ownerID uuid.UUID, comment *models.Comment, users []models.User, skip map[uuid.UUID]bool) {
+	todo, err := s.todos.GetTodoByIDForUser(ownerID, comment.TodoID)
+	if err != nil {
+		log.Printf("Failed to load todo %s for mention notices: %v", comment.TodoID, err)
+		return
+	}
+	for _, u := range users {
+		if u.ID == author || skip[u.ID] {
+			continue
+		}
+		err := s.mail.Send(mailer.Message{
+			To:      u.Email,
+			Subject: fmt.Sprintf("%s mentioned you on %q", comment.AuthorName, todo.Title),
+			Body: fmt.Sprintf("Hi %s,\n\n%s mentioned you in a comment on %q:\n\n%s\n\n"+
+				"See the whole thread with GET %s/api/v1/todos/%s/comments.",
+				u.Name, comment.AuthorName, todo.Title, comment.Body, AppURL(), todo.ID),
+		})
+		if err != nil {
+			log.Printf("Failed to send mention notice for comment %s: %v", comment.ID, err)
+		}
+	}
+}
+
+// CreateComment adds a comment to the todo. Anyone who can see the todo
+// can comment on it.
+func (s *commentService) CreateComment(userID uuid.UUID, todoID uuid.UUID, req *models.CreateCommentRequest) (*models.Comment, error) {
+	ownerID, _, err := s.todos.GetTodoAccess(userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+	body, err := commentBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	mentioned, err := s.resolveMentions(todoID, body)
+	if err != nil {
+		return nil, err
+	}
+	author, err := s.users.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	comment := &models.Comment{TodoID: todoID, AuthorID: &userID, Body: body, AuthorName: author.Name}
+	if err := s.repo.CreateComment(comment, userIDs(mentioned)); err != nil {
+		return nil, err
+	}
+	s.notifyMentions(userID, ownerID, comment, mentioned, nil)
+	return comment, nil
+}
+
+// GetComments pages through the todo's thread, oldest first.
+func (s *commentService) GetComments(userID uuid.UUID, todoID uuid.UUID, page models.PageRequest) (*models.CommentPage, error) {
+	if _, _, err := s.todos.GetTodoAccess(userID, todoID); err != nil {
+		return nil, err
+	}
+	if page.Limit <= 0 {
+		page.Limit = models.DefaultPageLimit
+	}
+	if page.Limit > models.MaxPageLimit {
+		page.Limit = models.MaxPageLimit
+	}
+	if page.Cursor != nil && page.Cursor.Backward {
+		return nil, models.ErrInvalidCursor
+	}
+	limit := page.Limit
+	// fetch one extra row to learn whether another page exists
+	page.Limit++
+	comments, err := s.repo.GetComments(todoID, page)
+	if err != nil {
+		return nil, err
+	}
+	result := &models.CommentPage{Comments: comments, HasMore: len(comments) > limit}
+	if result.HasMore {
+		result.Comments = comments[:limit]
+		result.NextCursor = repository.CommentCursor(&result.Comments[limit-1]).Encode()
+	}
+	return result, nil
+}
+
+// UpdateComment changes the body of the caller's own comment. Users
+// mentioned for the first time are notified.
+func (s *commentService) UpdateComment(userID uuid.UUID, todoID uuid.UUID, id uuid.UUID, req *models.UpdateCommentRequest) (*models.Comment, error) {
+	ownerID, _, err := s.todos.GetTodoAccess(userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+	comment, err := s.repo.GetComment(todoID, id)
+	if err != nil {
+		return nil, err
+	}
+	if comment.AuthorID == nil || *comment.AuthorID != userID {
+		return nil, models.ErrForbidden
+	}
+	body, err := commentBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	mentioned, err := s.resolveMentions(todoID, body)
+	if err != nil {
+		return nil, err
+	}
+	already := map[uuid.UUID]bool{}
+	for _, m := range comment.Mentions {
+		already[m.UserID] = true
+	}
+	comment.Body = body
+	if err := s.repo.UpdateComment(comment, userIDs(mentioned)); err != nil {
+		return nil, err
+	}
+	s.notifyMentions(userID, ownerID, comment, mentioned, already)
+	return comment, nil
+}
+
+// DeleteComment removes a comment. Authors can delete their own comments
+// and the todo's owner can delete any.
+func (s *commentService) DeleteComment(userID uuid.UUID, todoID uuid.UUID, id uuid.UUID) error {
+	_, role, err := s.todos.GetTodoAccess(userID, todoID)
+	if err != nil {
+		return err
+	}
+	comment, err := s.repo.GetComment(todoID, id)
+	if err != nil {
+		return err
+	}
+	isAuthor := comment.AuthorID != nil && *comment.AuthorID == userID
+	if !isAuthor && role != models.ShareOwner {
+		return models.ErrForbidden
+	}
+	return s.repo.DeleteComment(todoID, id)
+}
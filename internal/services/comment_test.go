@@ -0,0 +1,65 @@
+package services
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/danieldzansi/todo-api/internal/dbtest"
+	"github.com/danieldzansi/todo-api/internal/mailer"
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// recordingMailer keeps the messages it is asked to send.
+type recordingMailer struct {
+	mu   sync.Mutex
+	sent []mailer.Message
+}
+
+func (m *recordingMailer) Send(msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestCommentMentionIgnoresEmailCase(t *testing.T) {
+	db := dbtest.Open(t)
+	users := repository.NewUserRepository(db)
+	todos := repository.NewTodoRepository(db)
+	workspaces := repository.NewWorkspaceRepository(db)
+	mail := &recordingMailer{}
+	svc := NewCommentService(repository.NewCommentRepository(db), todos, users, mail)
+
+	author := models.User{ID: uuid.New(), Name: "Bo", Email: "bo@example.com", Password: "x"}
+	ana := models.User{ID: uuid.New(), Name: "Ana", Email: "Ana@Example.com", Password: "x"}
+	for _, u := range []models.User{author, ana} {
+		if err := users.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	workspace := &models.Workspace{Name: "Team", CreatedBy: &author.ID}
+	if err := workspaces.CreateWorkspace(workspace); err != nil {
+		t.Fatal(err)
+	}
+	err := workspaces.AddMember(&models.WorkspaceMembership{WorkspaceID: workspace.ID, UserID: ana.ID, Role: models.WorkspaceMember})
+	if err != nil {
+		t.Fatal(err)
+	}
+	todo := &models.Todo{Title: "Review", UserID: author.ID, WorkspaceID: &workspace.ID}
+	if err := todos.CreateTodo(todo); err != nil {
+		t.Fatal(err)
+	}
+
+	comment, err := svc.CreateComment(author.ID, todo.ID, &models.CreateCommentRequest{Body: "thanks @ana@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comment.Mentions) != 1 || comment.Mentions[0].UserID != ana.ID {
+		t.Fatalf("mentions = %+v, want Ana", comment.Mentions)
+	}
+	if len(mail.sent) != 1 || mail.sent[0].To != ana.Email {
+		t.Errorf("sent %+v, want one notice to %s", mail.sent, ana.Email)
+	}
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/repository"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// loginWindow is how far back failed attempts are counted.
+	loginWindow = 15 * time.Minute
+	// After accountDelayAfter failures an account must wait before each new
+	// attempt, doubling every time, until accountLockoutAfter failures lock
+	// it for loginLockout.
+	accountDelayAfter   = 3
+	accountLockoutAfter = 5
+	// ipLockoutAfter failures from one address, across any accounts, lock
+	// that address out.
+	ipLockoutAfter = 20
+	loginLockout   = 15 * time.Minute
+)
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// compareDummyPassword burns the same time as checking a real password, so
+// response times don't tell whether an email has an account.
+func compareDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+	})
+	bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// accountDelay is how long an account with the given number of recent
+// failures has to wait after the latest one.
+func accountDelay(failures int) time.Duration {
+	switch {
+	case failures >= accountLockoutAfter:
+		return loginLockout
+	case failures >= accountDelayAfter:
+		return time.Second << (failures - accountDelayAfter + 1)
+	}
+	return 0
+}
+
+// checkLoginThrottle returns a *LoginThrottledError if email or ip has to
+// wait before trying again. Unknown emails are throttled exactly like real
+// ones.
+func (s *AuthServiceImpl) checkLoginThrottle(email, ip string) error {
+	now := time.Now()
+	since := now.Add(-loginWindow)
+
+	wait := time.Duration(0)
+	n, last, err := s.attempts.RecentFailuresByEmail(email, since)
+	if err != nil {
+		return err
+	}
+	if d := accountDelay(n); d > 0 && last != nil {
+		if w := last.Add(d).Sub(now); w > wait {
+			wait = w
+		}
+	}
+	if ip != "" {
+		n, last, err = s.attempts.RecentFailuresByIP(ip, since)
+		if err != nil {
+			return err
+		}
+		if n >= ipLockoutAfter && last != nil {
+			if w := last.Add(loginLockout).Sub(now); w > wait {
+				wait = w
+			}
+		}
+	}
+	if wait > 0 {
+		return &models.LoginThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+func (s *AuthServiceImpl) recordLoginAttempt(email string, userID *uuid.UUID, ip string, reason string) error {
+	return s.attempts.RecordLoginAttempt(&models.LoginAttempt{
+		Email:   email,
+		UserID:  userID,
+		IP:      ip,
+		Success: reason == "",
+		Reason:  reason,
+	})
+}
+
+// loginFailed records a failed attempt and returns err for the caller to
+// pass on.
+func (s *AuthServiceImpl) loginFailed(email string, userID *uuid.UUID, ip string, reason string, err error) error {
+	if recErr := s.recordLoginAttempt(email, userID, ip, reason); recErr != nil {
+		return recErr
+	}
+	return err
+}
+
+// LoginAttemptPurger periodically deletes login attempts older than the
+// retention period. Throttling only looks back loginWindow, so anything
+// older is kept purely as an audit trail.
+type LoginAttemptPurger struct {
+	repo      repository.LoginAttemptRepository
+	retention time.Duration
+	interval  time.Duration
+	done      chan struct{}
+}
+
+func NewLoginAttemptPurger(repo repository.LoginAttemptRepository, retention, interval time.Duration) *LoginAttemptPurger {
+	return &LoginAttemptPurger{repo: repo, retention: retention, interval: interval, done: make(chan struct{})}
+}
+
+// Start runs a purge right away and then once per interval until Stop is
+// called.
+func (p *LoginAttemptPurger) Start() {
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			p.purge()
+			select {
+			case <-ticker.C:
+			case <-p.done:
+				return
+			}
+		}
+	}()
+}
+
+func (p *LoginAttemptPurger) Stop() {
+	close(p.done)
+}
+
+func (p *LoginAttemptPurger) purge() {
+	n, err := p.repo.DeleteLoginAttemptsBefore(time.Now().Add(-p.retention))
+	if err != nil {
+		log.Println("Failed to purge login attempts:", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("Purged %d login attempts", n)
+	}
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/oidc"
+	"github.com/danieldzansi/todo-api/internal/repository"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+type OIDCService interface {
+	Providers() []string
+	StartLogin(provider string) (string, error)
+	FinishLogin(provider, code, state string) (*models.LoginResponse, error)
+}
+
+type oidcService struct {
+	providers map[string]*oidc.Provider
+	repo      repository.OIDCRepository
+	users     repository.UserRepository
+	roles     repository.RoleRepository
+	auth      AuthService
+}
+
+func NewOIDCService(providers []*oidc.Provider, r repository.OIDCRepository, users repository.UserRepository, roles repository.RoleRepository, auth AuthService) OIDCService {
+	byName := make(map[string]*oidc.Provider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+	return &oidcService{providers: byName, repo: r, users: users, roles: roles, auth: auth}
+}
+
+func (s *oidcService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StartLogin returns the provider URL to redirect the browser to.
+func (s *oidcService) StartLogin(provider string) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", models.ErrOIDCProviderNotFound
+	}
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		return "", err
+	}
+	err = s.repo.CreateState(&models.OIDCState{
+		StateHash:    hashToken(state),
+		Provider:     provider,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return p.AuthCodeURL(state, nonce, challenge)
+}
+
+// FinishLogin handles the provider's callback and logs the user in with the
+// same tokens Login issues, or the same two-factor challenge for accounts
+// that have it turned on. Identities already linked sign straight in;
+// otherwise a verified email links to the existing account with that email,
+// provided that account has verified it too, or creates a new one.
+func (s *oidcService) FinishLogin(provider, code, state string) (*models.LoginResponse, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, models.ErrOIDCProviderNotFound
+	}
+	st, err := s.repo.ConsumeState(hashToken(state))
+	if err != nil {
+		return nil, err
+	}
+	if st.Provider != provider {
+		return nil, models.ErrInvalidOIDCState
+	}
+	claims, err := p.Exchange(code, st.CodeVerifier, st.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userFor(provider, claims)
+	if err != nil {
+		return nil, err
+	}
+	return s.auth.IssueTokens(user)
+}
+
+func (s *oidcService) userFor(provider string, claims *oidc.Claims) (*models.User, error) {
+	identity, err := s.repo.GetIdentity(claims.Issuer, claims.Subject)
+	if err == nil {
+		return s.users.GetUserByID(identity.UserID)
+	}
+	if err != models.ErrUserNotFound {
+		return nil, err
+	}
+
+	// linking by email is only safe if the provider vouches for it
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, models.ErrOIDCEmailNotVerified
+	}
+	user, err := s.users.GetUserByEmail(claims.Email)
+	switch err {
+	case nil:
+		// anyone can sign up with an address they don't own, so only an
+		// account that proved it owns the address may be linked; otherwise
+		// whoever created it could still sign in to it afterwards
+		if user.EmailVerifiedAt == nil {
+			return nil, models.ErrOIDCAccountNotVerified
+		}
+	case models.ErrUserNotFound:
+		if user, err = s.createUser(claims); err != nil {
+			return nil, err
+		}
+		if err := s.users.MarkEmailVerified(user.ID); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	default:
+		return nil, err
+	}
+	err = s.repo.CreateIdentity(&models.UserIdentity{
+		UserID:   user.ID,
+		Provider: provider,
+		Issuer:   claims.Issuer,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Linked %s identity %s to user %s", provider, claims.Subject, user.ID)
+	return user, nil
+}
+
+// createUser signs up someone who has only ever used the identity
+// provider. Their password is random; they can set one with a reset.
+func (s *oidcService) createUser(claims *oidc.Claims) (*models.User, error) {
+	random, _, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(random), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	name := claims.Name
+	if name == "" {
+		name = strings.SplitN(claims.Email, "@", 2)[0]
+	}
+	now := time.Now()
+	user := &models.User{
+		ID:        uuid.New(),
+		Name:      name,
+		Email:     claims.Email,
+		Password:  string(hashed),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.users.CreateUser(*user); err != nil {
+		return nil, err
+	}
+	if err := s.roles.AddUserRole(user.ID, models.RoleUser); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/danieldzansi/todo-api/internal/mailer"
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	defaultPasswordResetTTL            = time.Hour
+	defaultPasswordResetResendInterval = time.Minute
+)
+
+// issueUserToken stores a new single-use token for user and returns the
+// plaintext to mail out.
+func (s *AuthServiceImpl) issueUserToken(userID uuid.UUID, purpose models.TokenPurpose, ttl time.Duration) (string, time.Time, error) {
+	token, hash, err := newOpaqueToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	t := &models.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.tokens.CreateUserToken(t); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, t.ExpiresAt, nil
+}
+
+// ForgotPassword mails a reset token to the account with the given email.
+// The lookup and the mail happen in the background and problems are only
+// logged, so the request takes the same time and gets the same answer
+// whether or not the account exists.
+func (s *AuthServiceImpl) ForgotPassword(req *models.ForgotPasswordRequest) error {
+	go func(email string) {
+		if err := s.sendPasswordReset(email); err != nil {
+			log.Printf("Failed to send password reset: %v", err)
+		}
+	}(req.Email)
+	return nil
+}
+
+// sendPasswordReset issues and mails a reset token, at most once per
+// PASSWORD_RESET_RESEND_INTERVAL. Unknown addresses and throttled requests
+// are silently ignored.
+func (s *AuthServiceImpl) sendPasswordReset(email string) error {
+	user, err := s.repo.GetUserByEmail(email)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			return nil
+		}
+		return err
+	}
+	last, err := s.tokens.LastUserTokenAt(user.ID, models.PurposePasswordReset)
+	if err != nil {
+		return err
+	}
+	interval := durationFromEnv("PASSWORD_RESET_RESEND_INTERVAL", defaultPasswordResetResendInterval)
+	if last != nil && time.Since(*last) < interval {
+		return nil
+	}
+	token, expiresAt, err := s.issueUserToken(user.ID, models.PurposePasswordReset, durationFromEnv("PASSWORD_RESET_TTL", defaultPasswordResetTTL))
+	if err != nil {
+		return err
+	}
+	return s.mail.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password for your account. "+
+			"To choose a new one, send this token to POST /api/v1/users/password/reset:\n\n%s\n\n"+
+			"It can be used once and expires at %s. If you didn't ask for this you can ignore this mail.",
+			user.Name, token, expiresAt.UTC().Format(time.RFC1123)),
+	})
+}
+
+// ResetPassword sets a new password using a token from ForgotPassword and
+// logs the user out everywhere.
+func (s *AuthServiceImpl) ResetPassword(req *models.ResetPasswordRequest) error {
+	t, err := s.tokens.ConsumeUserToken(models.PurposePasswordReset, hashToken(req.Token))
+	if err != nil {
+		return err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UpdatePassword(t.UserID, string(hashed)); err != nil {
+		return err
+	}
+	return s.LogoutAll(t.UserID)
+}
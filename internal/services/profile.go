@@ -0,0 +1,163 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/danieldzansi/todo-api/internal/mailer"
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const defaultEmailChangeTTL = 24 * time.Hour
+
+// checkPassword loads the user and makes sure password is theirs.
+func (s *AuthServiceImpl) checkPassword(userID uuid.UUID, password string) (*models.User, error) {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return nil, models.ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+func (s *AuthServiceImpl) UpdateProfile(userID uuid.UUID, req *models.UpdateProfileRequest) (*models.User, error) {
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, models.ErrInvalidName
+		}
+		if err := s.repo.UpdateName(userID, name); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetUserByID(userID)
+}
+
+// ChangePassword sets a new password after checking the current one. Every
+// other session is logged out; the caller gets fresh tokens to carry on
+// with.
+func (s *AuthServiceImpl) ChangePassword(userID uuid.UUID, req *models.ChangePasswordRequest) (*models.LoginResponse, error) {
+	user, err := s.checkPassword(userID, req.CurrentPassword)
+	if err != nil {
+		return nil, err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdatePassword(userID, string(hashed)); err != nil {
+		return nil, err
+	}
+	if err := s.LogoutAll(userID); err != nil {
+		return nil, err
+	}
+	return s.issueTokens(user, uuid.New())
+}
+
+// RequestEmailChange mails a confirmation link to the new address. The
+// account keeps its current email until the link is followed, and the old
+// address is told about the request.
+func (s *AuthServiceImpl) RequestEmailChange(userID uuid.UUID, req *models.ChangeEmailRequest) error {
+	user, err := s.checkPassword(userID, req.Password)
+	if err != nil {
+		return err
+	}
+	newEmail := strings.TrimSpace(req.NewEmail)
+	if normalizeEmail(newEmail) == normalizeEmail(user.Email) {
+		return models.ErrSameEmail
+	}
+	if _, err := s.repo.GetUserByEmail(newEmail); err == nil {
+		return models.ErrUserAlreadyExists
+	} else if err != models.ErrUserNotFound {
+		return err
+	}
+
+	token, hash, err := newOpaqueToken()
+	if err != nil {
+		return err
+	}
+	t := &models.UserToken{
+		UserID:    userID,
+		Purpose:   models.PurposeEmailChange,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(durationFromEnv("EMAIL_CHANGE_TTL", defaultEmailChangeTTL)),
+		Email:     &newEmail,
+	}
+	if err := s.tokens.CreateUserToken(t); err != nil {
+		return err
+	}
+	link := AppURL() + "/api/v1/users/me/email/confirm?token=" + url.QueryEscape(token)
+	err = s.mail.Send(mailer.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Hi %s,\n\nTo use this address for your account, open:\n\n%s\n\n"+
+			"The link expires at %s.",
+			user.Name, link, t.ExpiresAt.UTC().Format(time.RFC1123)),
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.mail.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to change the email address of your account to %s. "+
+			"If this wasn't you, reset your password straight away.", user.Name, newEmail),
+	}); err != nil {
+		log.Printf("Failed to send email change notice to user %s: %v", user.ID, err)
+	}
+	return nil
+}
+
+// ConfirmEmailChange switches to the address a RequestEmailChange token was
+// mailed to. Following the link proves ownership, so it counts as verified.
+func (s *AuthServiceImpl) ConfirmEmailChange(req *models.ConfirmEmailChangeRequest) error {
+	t, err := s.tokens.ConsumeUserToken(models.PurposeEmailChange, hashToken(req.Token))
+	if err != nil {
+		return err
+	}
+	if t.Email == nil {
+		return models.ErrInvalidUserToken
+	}
+	return s.repo.UpdateEmail(t.UserID, *t.Email)
+}
+
+// DeleteAccount deletes the caller's account after checking their password
+// and, with 2FA on, a code. The account's todos are deleted with it or kept
+// under an anonymized tombstone, as req.Todos says.
+func (s *AuthServiceImpl) DeleteAccount(userID uuid.UUID, req *models.DeleteAccountRequest) error {
+	mode := req.Todos
+	if mode == "" {
+		mode = models.DeleteTodos
+	}
+	if mode != models.DeleteTodos && mode != models.AnonymizeTodos {
+		return models.ErrInvalidDeleteMode
+	}
+	user, err := s.checkPassword(userID, req.Password)
+	if err != nil {
+		return err
+	}
+	if user.TOTPEnabledAt != nil {
+		if err := s.checkSecondFactor(userID, req.Code, true); err != nil {
+			return err
+		}
+	}
+
+	if mode == models.AnonymizeTodos {
+		err = s.repo.AnonymizeUser(userID)
+	} else {
+		err = s.repo.DeleteUser(userID)
+	}
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	s.revoked.setCutoff(userID, &now)
+	return nil
+}
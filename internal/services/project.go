@@ -0,0 +1,86 @@
+package services
+
+import (
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+type ProjectService interface {
+	CreateProjectForUser(userID uuid.UUID, workspaceID *uuid.UUID, req *models.CreateProjectRequest) (*models.Project, error)
+	GetProjectsByUser(userID uuid.UUID, workspaceID *uuid.UUID, includeArchived bool) ([]models.Project, error)
+	GetProjectByIDForUser(userID uuid.UUID, id uuid.UUID) (*models.Project, error)
+	UpdateProjectForUser(userID uuid.UUID, id uuid.UUID, req *models.UpdateProjectRequest) (*models.Project, error)
+	DeleteProjectForUser(userID uuid.UUID, id uuid.UUID, policy models.ProjectDeletePolicy) error
+}
+
+type projectService struct {
+	repo repository.ProjectRepository
+}
+
+func NewProjectService(r repository.ProjectRepository) ProjectService {
+	return &projectService{repo: r}
+}
+
+func (s *projectService) CreateProjectForUser(userID uuid.UUID, workspaceID *uuid.UUID, req *models.CreateProjectRequest) (*models.Project, error) {
+	project := &models.Project{
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		Name:        req.Name,
+		Color:       req.Color,
+		SortOrder:   req.SortOrder,
+	}
+	if err := s.repo.CreateProject(project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+func (s *projectService) GetProjectsByUser(userID uuid.UUID, workspaceID *uuid.UUID, includeArchived bool) ([]models.Project, error) {
+	return s.repo.GetProjectsByUser(userID, workspaceID, includeArchived)
+}
+
+// GetProjectByIDForUser also finds projects shared with the user.
+func (s *projectService) GetProjectByIDForUser(userID uuid.UUID, id uuid.UUID) (*models.Project, error) {
+	ownerID, _, err := s.repo.GetProjectAccess(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetProjectByIDForUser(ownerID, id)
+}
+
+// authorizeOwner checks that the user may manage the project: they own it,
+// or it is in a workspace where they created it or are an owner or admin.
+func (s *projectService) authorizeOwner(userID uuid.UUID, id uuid.UUID) (uuid.UUID, error) {
+	ownerID, role, err := s.repo.GetProjectAccess(userID, id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if role != models.ShareOwner {
+		return uuid.Nil, models.ErrForbidden
+	}
+	return ownerID, nil
+}
+
+func (s *projectService) UpdateProjectForUser(userID uuid.UUID, id uuid.UUID, req *models.UpdateProjectRequest) (*models.Project, error) {
+	ownerID, err := s.authorizeOwner(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.UpdateProjectForUser(ownerID, id, req)
+}
+
+func (s *projectService) DeleteProjectForUser(userID uuid.UUID, id uuid.UUID, policy models.ProjectDeletePolicy) error {
+	switch policy {
+	case "":
+		policy = models.DeleteMoveToInbox
+	case models.DeleteMoveToInbox, models.DeleteCascade:
+	default:
+		return models.ErrInvalidDeletePolicy
+	}
+	ownerID, err := s.authorizeOwner(userID, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteProjectForUser(ownerID, id, policy)
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const defaultRevocationCacheTTL = 30 * time.Second
+
+// revocationCache keeps recent revocation lookups in memory so that
+// AuthMiddleware doesn't hit Postgres on every request. A revoked jti stays
+// revoked, so it is remembered until the token expires; negative answers and
+// per-user cutoffs are only trusted for ttl, which bounds how long a
+// revocation made by another instance takes to be noticed here.
+type revocationCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	tokens    map[uuid.UUID]cachedRevocation
+	cutoffs   map[uuid.UUID]cachedCutoff
+	lastSweep time.Time
+}
+
+type cachedRevocation struct {
+	revoked bool
+	until   time.Time
+}
+
+type cachedCutoff struct {
+	after *time.Time
+	until time.Time
+}
+
+func newRevocationCache(ttl time.Duration) *revocationCache {
+	return &revocationCache{
+		ttl:     ttl,
+		tokens:  make(map[uuid.UUID]cachedRevocation),
+		cutoffs: make(map[uuid.UUID]cachedCutoff),
+	}
+}
+
+// token reports the cached state of jti and whether there was one.
+func (c *revocationCache) token(jti uuid.UUID) (revoked bool, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.tokens[jti]
+	if !ok || time.Now().After(e.until) {
+		return false, false
+	}
+	return e.revoked, true
+}
+
+func (c *revocationCache) setToken(jti uuid.UUID, revoked bool, expiresAt time.Time) {
+	until := time.Now().Add(c.ttl)
+	if revoked || expiresAt.Before(until) {
+		until = expiresAt
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokens[jti] = cachedRevocation{revoked: revoked, until: until}
+	c.sweep()
+}
+
+func (c *revocationCache) cutoff(userID uuid.UUID) (after *time.Time, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.cutoffs[userID]
+	if !ok || time.Now().After(e.until) {
+		return nil, false
+	}
+	return e.after, true
+}
+
+func (c *revocationCache) setCutoff(userID uuid.UUID, after *time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cutoffs[userID] = cachedCutoff{after: after, until: time.Now().Add(c.ttl)}
+	c.sweep()
+}
+
+// sweep drops stale entries at most once per ttl. c.mu must be held.
+func (c *revocationCache) sweep() {
+	now := time.Now()
+	if now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+	c.lastSweep = now
+	for k, e := range c.tokens {
+		if now.After(e.until) {
+			delete(c.tokens, k)
+		}
+	}
+	for k, e := range c.cutoffs {
+		if now.After(e.until) {
+			delete(c.cutoffs, k)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"log"
+	"strings"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+type RoleService interface {
+	GetRoles() ([]models.Role, error)
+	GetRole(name string) (*models.Role, error)
+	CreateRole(req *models.CreateRoleRequest) (*models.Role, error)
+	UpdateRole(name string, req *models.UpdateRoleRequest) (*models.Role, error)
+	DeleteRole(name string) error
+	GetUserRoles(userID uuid.UUID) ([]models.Role, error)
+	SetUserRoles(userID uuid.UUID, req *models.SetUserRolesRequest) ([]models.Role, error)
+	BootstrapAdmins(emails []string) error
+}
+
+type roleService struct {
+	repo  repository.RoleRepository
+	users repository.UserRepository
+}
+
+func NewRoleService(r repository.RoleRepository, users repository.UserRepository) RoleService {
+	return &roleService{repo: r, users: users}
+}
+
+func (s *roleService) GetRoles() ([]models.Role, error) {
+	return s.repo.GetRoles()
+}
+
+func (s *roleService) GetRole(name string) (*models.Role, error) {
+	return s.repo.GetRoleByName(name)
+}
+
+func (s *roleService) CreateRole(req *models.CreateRoleRequest) (*models.Role, error) {
+	if err := models.ValidateRoleName(req.Name); err != nil {
+		return nil, err
+	}
+	perms := uniqueStrings(req.Permissions)
+	if err := models.ValidatePermissions(perms); err != nil {
+		return nil, err
+	}
+	role := &models.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: perms,
+	}
+	if err := s.repo.CreateRole(role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+func (s *roleService) UpdateRole(name string, req *models.UpdateRoleRequest) (*models.Role, error) {
+	if req.Permissions != nil {
+		perms := uniqueStrings(*req.Permissions)
+		if err := models.ValidatePermissions(perms); err != nil {
+			return nil, err
+		}
+		req.Permissions = &perms
+	}
+	return s.repo.UpdateRole(name, req)
+}
+
+func (s *roleService) DeleteRole(name string) error {
+	return s.repo.DeleteRole(name)
+}
+
+func (s *roleService) GetUserRoles(userID uuid.UUID) ([]models.Role, error) {
+	if _, err := s.users.GetUserByID(userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetUserRoles(userID)
+}
+
+// SetUserRoles replaces the user's roles. Changes reach the user's access
+// tokens the next time they log in or refresh.
+func (s *roleService) SetUserRoles(userID uuid.UUID, req *models.SetUserRolesRequest) ([]models.Role, error) {
+	if _, err := s.users.GetUserByID(userID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetUserRoles(userID, uniqueStrings(req.Roles)); err != nil {
+		return nil, err
+	}
+	return s.repo.GetUserRoles(userID)
+}
+
+// BootstrapAdmins grants the admin role to the accounts with the given
+// emails, so a fresh install has someone who can manage roles. Unknown
+// emails are logged and skipped.
+func (s *roleService) BootstrapAdmins(emails []string) error {
+	for _, email := range emails {
+		email = strings.TrimSpace(email)
+		if email == "" {
+			continue
+		}
+		user, err := s.users.GetUserByEmail(email)
+		if err != nil {
+			if err == models.ErrUserNotFound {
+				log.Printf("Admin %s has not signed up yet", email)
+				continue
+			}
+			return err
+		}
+		if err := s.repo.AddUserRole(user.ID, models.RoleAdmin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func uniqueStrings(ss []string) []string {
+	seen := make(map[string]bool, len(ss))
+	out := make([]string, 0, len(ss))
+	for _, s := range ss {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
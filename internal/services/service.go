@@ -1,13 +1,14 @@
 package services
 
 import (
-	"errors"
-	"os"
+	"fmt"
 	"time"
 
+	"github.com/danieldzansi/todo-api/internal/mailer"
 	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/recurrence"
 	"github.com/danieldzansi/todo-api/internal/repository"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/danieldzansi/todo-api/internal/signing"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -19,20 +20,92 @@ type TodoService interface {
 	UpdateTodo(id uuid.UUID, req *models.UpdateTodoRequest) (*models.Todo, error)
 	DeleteTodo(id uuid.UUID) error
 	ToggleTodoComplete(id uuid.UUID) (*models.Todo, error)
-	CreateTodoForUser(userID uuid.UUID, req *models.CreateTodoRequest) (*models.Todo, error)
+	CreateTodoForUser(userID uuid.UUID, workspaceID *uuid.UUID, req *models.CreateTodoRequest) (*models.Todo, error)
 	GetAllTodosByUser(userID uuid.UUID) ([]models.Todo, error)
+	ListTodosByUser(userID uuid.UUID, filter models.TodoFilter, page models.PageRequest) (*models.TodoPage, error)
+	SearchTodosByUser(userID uuid.UUID, workspaceID *uuid.UUID, q string, limit int) ([]models.TodoSearchResult, error)
 	GetTodoByIDForUser(userID uuid.UUID, id uuid.UUID) (*models.Todo, error)
 	UpdateTodoForUser(userID uuid.UUID, id uuid.UUID, req *models.UpdateTodoRequest) (*models.Todo, error)
 	DeleteTodoForUser(userID uuid.UUID, id uuid.UUID) error
-	ToggleTodoCompleteForUser(userID uuid.UUID, id uuid.UUID) (*models.Todo, error)
+	ToggleTodoCompleteForUser(userID uuid.UUID, id uuid.UUID, cascade bool) (*models.Todo, error)
+	MoveTodoForUser(userID uuid.UUID, id uuid.UUID, projectID *uuid.UUID) (*models.Todo, error)
+	CreateSubtaskForUser(userID uuid.UUID, parentID uuid.UUID, req *models.CreateTodoRequest) (*models.Todo, error)
+	GetSubtasksForUser(userID uuid.UUID, parentID uuid.UUID) ([]models.Todo, error)
+	ReorderSubtasksForUser(userID uuid.UUID, parentID uuid.UUID, ids []uuid.UUID) ([]models.Todo, error)
+	GetTrashByUser(userID uuid.UUID, workspaceID *uuid.UUID) ([]models.Todo, error)
+	RestoreTodoForUser(userID uuid.UUID, workspaceID *uuid.UUID, id uuid.UUID) (*models.Todo, error)
+	PurgeTodoForUser(userID uuid.UUID, workspaceID *uuid.UUID, id uuid.UUID) error
+	EmptyTrashForUser(userID uuid.UUID, workspaceID *uuid.UUID) (int64, error)
+	AssignTodoForUser(userID uuid.UUID, id uuid.UUID, assigneeID uuid.UUID) (*models.Todo, error)
+	UnassignTodoForUser(userID uuid.UUID, id uuid.UUID) (*models.Todo, error)
+	GetAssignedTodos(userID uuid.UUID, workspaceID *uuid.UUID) ([]models.Todo, error)
+	GetWorkload(userID uuid.UUID, workspaceID *uuid.UUID) ([]models.Workload, error)
 }
 
 type todoService struct {
-	repo repository.TodoRepository
+	repo       repository.TodoRepository
+	projects   repository.ProjectRepository
+	workspaces repository.WorkspaceRepository
 }
 
-func NewTodoService(r repository.TodoRepository) TodoService {
-	return &todoService{repo: r}
+func NewTodoService(r repository.TodoRepository, projects repository.ProjectRepository, workspaces repository.WorkspaceRepository) TodoService {
+	return &todoService{repo: r, projects: projects, workspaces: workspaces}
+}
+
+// checkProject makes sure a todo owned by ownerID can only be filed under
+// a project in the same space: the owner's own projects for personal
+// todos, any project of the workspace for workspace todos.
+func (s *todoService) checkProject(userID uuid.UUID, ownerID uuid.UUID, workspaceID *uuid.UUID, projectID *uuid.UUID) error {
+	if projectID == nil {
+		return nil
+	}
+	projectOwnerID, err := s.projectOwner(userID, workspaceID, projectID)
+	if err != nil {
+		return err
+	}
+	if workspaceID == nil && projectOwnerID != ownerID {
+		return models.ErrProjectNotFound
+	}
+	return nil
+}
+
+// projectOwner returns the owner of projectID, which must be in the given
+// workspace (nil for personal space) and editable by the caller: their
+// own, one shared with them as editor, or one of the workspace's.
+func (s *todoService) projectOwner(userID uuid.UUID, workspaceID *uuid.UUID, projectID *uuid.UUID) (uuid.UUID, error) {
+	if projectID == nil {
+		return userID, nil
+	}
+	ownerID, role, err := s.projects.GetProjectAccess(userID, *projectID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !role.Allows(models.ShareEditor) {
+		return uuid.Nil, models.ErrForbidden
+	}
+	project, err := s.projects.GetProjectByIDForUser(ownerID, *projectID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !sameWorkspace(project.WorkspaceID, workspaceID) {
+		return uuid.Nil, models.ErrWrongWorkspace
+	}
+	return ownerID, nil
+}
+
+// authorize checks that the user holds at least need on the todo, whether
+// they own it or it was shared with them, and returns the owner the
+// repository calls are scoped by. Todos the user can't see at all are
+// ErrTodoNotFound.
+func (s *todoService) authorize(userID uuid.UUID, id uuid.UUID, need models.ShareRole) (uuid.UUID, error) {
+	ownerID, role, err := s.repo.GetTodoAccess(userID, id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !role.Allows(need) {
+		return uuid.Nil, models.ErrForbidden
+	}
+	return ownerID, nil
 }
 func (s *todoService) CreateTodo(req *models.CreateTodoRequest) (*models.Todo, error) {
 	todo := &models.Todo{
@@ -49,13 +122,40 @@ func (s *todoService) CreateTodo(req *models.CreateTodoRequest) (*models.Todo, e
 
 	return todo, nil
 }
-func (s *todoService) CreateTodoForUser(userID uuid.UUID, req *models.CreateTodoRequest) (*models.Todo, error) {
+
+// CreateTodoForUser creates a todo in the user's personal space, or in
+// workspaceID when set. Personal todos filed under a shared project belong
+// to the project's owner; workspace todos always belong to their creator.
+func (s *todoService) CreateTodoForUser(userID uuid.UUID, workspaceID *uuid.UUID, req *models.CreateTodoRequest) (*models.Todo, error) {
 	todo := &models.Todo{
 		Title:       req.Title,
 		Description: req.Description,
+		Priority:    req.Priority,
 		DueDate:     req.DueDate,
 		Completed:   false,
-		UserID:      userID,
+		ProjectID:   req.ProjectID,
+		WorkspaceID: workspaceID,
+		Tags:        req.Tags,
+	}
+	ownerID, err := s.projectOwner(userID, workspaceID, todo.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	todo.UserID = ownerID
+	if workspaceID != nil {
+		todo.UserID = userID
+	}
+	if err := s.checkNewAssignee(req.AssigneeID, todo); err != nil {
+		return nil, err
+	}
+	todo.AssigneeID = req.AssigneeID
+	if req.Recurrence != nil && *req.Recurrence != "" {
+		rule, err := parseRecurrence(*req.Recurrence)
+		if err != nil {
+			return nil, err
+		}
+		canonical := rule.String()
+		todo.Recurrence = &canonical
 	}
 	if err := s.repo.CreateTodo(todo); err != nil {
 		return nil, err
@@ -66,16 +166,57 @@ func (s *todoService) CreateTodoForUser(userID uuid.UUID, req *models.CreateTodo
 type AuthService interface {
 	Signup(req *models.SignupRequest) (*models.User, error)
 	Login(req *models.LoginRequest) (*models.LoginResponse, error)
+	Refresh(req *models.RefreshRequest) (*models.LoginResponse, error)
+	Authenticate(token string) (*models.AccessClaims, error)
+	Logout(claims *models.AccessClaims, req *models.LogoutRequest) error
+	LogoutAll(userID uuid.UUID) error
+	ForgotPassword(req *models.ForgotPasswordRequest) error
+	ResetPassword(req *models.ResetPasswordRequest) error
+	VerifyEmail(req *models.VerifyEmailRequest) error
+	ResendVerification(req *models.ResendVerificationRequest) error
+	IsEmailVerified(userID uuid.UUID) (bool, error)
+	CreatePersonalToken(userID uuid.UUID, req *models.CreatePersonalTokenRequest) (*models.CreatedPersonalToken, error)
+	GetPersonalTokens(userID uuid.UUID) ([]models.PersonalAccessToken, error)
+	RevokePersonalToken(userID uuid.UUID, id uuid.UUID) error
+	EnrollTOTP(userID uuid.UUID) (*models.TOTPEnrollment, error)
+	ConfirmTOTP(userID uuid.UUID, req *models.TOTPCodeRequest) (*models.RecoveryCodes, error)
+	DisableTOTP(userID uuid.UUID, req *models.DisableTOTPRequest) error
+	RegenerateRecoveryCodes(userID uuid.UUID, req *models.TOTPCodeRequest) (*models.RecoveryCodes, error)
+	VerifyLogin(req *models.VerifyLoginRequest) (*models.LoginResponse, error)
+	IssueTokens(user *models.User) (*models.LoginResponse, error)
 	GetUserByID(id uuid.UUID) (*models.User, error)
 	GetAllUsers() ([]models.User, error)
+	UpdateProfile(userID uuid.UUID, req *models.UpdateProfileRequest) (*models.User, error)
+	ChangePassword(userID uuid.UUID, req *models.ChangePasswordRequest) (*models.LoginResponse, error)
+	RequestEmailChange(userID uuid.UUID, req *models.ChangeEmailRequest) error
+	ConfirmEmailChange(req *models.ConfirmEmailChangeRequest) error
+	DeleteAccount(userID uuid.UUID, req *models.DeleteAccountRequest) error
 }
 
 type AuthServiceImpl struct {
-	repo repository.UserRepository
+	repo     repository.UserRepository
+	tokens   repository.TokenRepository
+	roles    repository.RoleRepository
+	totp     repository.TOTPRepository
+	attempts repository.LoginAttemptRepository
+	keys     *signing.KeySet
+	revoked  *revocationCache
+	mail     mailer.Mailer
+	verify   VerificationPolicy
 }
 
-func NewAuthService(repo repository.UserRepository) AuthService {
-	return &AuthServiceImpl{repo: repo}
+func NewAuthService(repo repository.UserRepository, tokens repository.TokenRepository, roles repository.RoleRepository, totp repository.TOTPRepository, attempts repository.LoginAttemptRepository, keys *signing.KeySet, mail mailer.Mailer, verify VerificationPolicy) AuthService {
+	return &AuthServiceImpl{
+		repo:     repo,
+		tokens:   tokens,
+		roles:    roles,
+		totp:     totp,
+		attempts: attempts,
+		keys:     keys,
+		mail:     mail,
+		verify:   verify,
+		revoked:  newRevocationCache(durationFromEnv("REVOCATION_CACHE_TTL", defaultRevocationCacheTTL)),
+	}
 }
 
 func (s *AuthServiceImpl) Signup(req *models.SignupRequest) (*models.User, error) {
@@ -101,44 +242,62 @@ func (s *AuthServiceImpl) Signup(req *models.SignupRequest) (*models.User, error
 	if err := s.repo.CreateUser(*user); err != nil {
 		return nil, err
 	}
+	if err := s.roles.AddUserRole(user.ID, models.RoleUser); err != nil {
+		return nil, err
+	}
+	user.Roles = []string{models.RoleUser}
+	s.sendVerificationOnSignup(user)
 	return user, nil
 }
 
 func (s *AuthServiceImpl) GetUserByID(id uuid.UUID) (*models.User, error) {
-	return s.repo.GetUserByID(id)
+	user, err := s.repo.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := s.roles.GetUserRoles(id)
+	if err != nil {
+		return nil, err
+	}
+	user.Roles = roleNames(roles)
+	return user, nil
 }
 func (s *AuthServiceImpl) GetAllUsers() ([]models.User, error) {
 	return s.repo.GetAllUsers()
 }
 
+// Login checks the password and either issues tokens or, with 2FA on,
+// starts a challenge. Every failure gives the same ErrInvalidCredentials so
+// callers can't tell whether the email exists; repeated failures are
+// throttled per email and per client IP.
 func (s *AuthServiceImpl) Login(req *models.LoginRequest) (*models.LoginResponse, error) {
+	email := normalizeEmail(req.Email)
+	if err := s.checkLoginThrottle(email, req.ClientIP); err != nil {
+		return nil, s.loginFailed(email, nil, req.ClientIP, models.LoginFailLocked, err)
+	}
+
 	user, err := s.repo.GetUserByEmail(req.Email)
 	if err != nil {
+		if err == models.ErrUserNotFound {
+			compareDummyPassword(req.Password)
+			return nil, s.loginFailed(email, nil, req.ClientIP, models.LoginFailUnknownEmail, models.ErrInvalidCredentials)
+		}
 		return nil, err
 	}
 	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)) != nil {
-		return nil, errors.New("invalid credentials")
+		return nil, s.loginFailed(email, &user.ID, req.ClientIP, models.LoginFailBadPassword, models.ErrInvalidCredentials)
 	}
-
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "dev-secret-change-me"
+	if s.verify == VerifyBeforeLogin && user.EmailVerifiedAt == nil {
+		return nil, models.ErrEmailNotVerified
 	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":   user.ID.String(),
-		"email": user.Email,
-		"exp":   time.Now().Add(24 * time.Hour).Unix(),
-		"iat":   time.Now().Unix(),
-	})
-	signed, err := token.SignedString([]byte(secret))
-	if err != nil {
+	if user.TOTPEnabledAt != nil {
+		// the attempt is recorded once the second factor is checked
+		return s.startLoginChallenge(user)
+	}
+	if err := s.recordLoginAttempt(email, &user.ID, req.ClientIP, ""); err != nil {
 		return nil, err
 	}
-	safeUser := *user
-	safeUser.Password = ""
-
-	return &models.LoginResponse{Token: signed, User: safeUser}, nil
+	return s.issueTokens(user, uuid.New())
 }
 
 func (s *todoService) GetAllTodos() ([]models.Todo, error) {
@@ -148,30 +307,268 @@ func (s *todoService) GetAllTodosByUser(userID uuid.UUID) ([]models.Todo, error)
 	return s.repo.GetAllTodosByUser(userID)
 }
 
+func (s *todoService) ListTodosByUser(userID uuid.UUID, filter models.TodoFilter, page models.PageRequest) (*models.TodoPage, error) {
+	if filter.Sort == "" {
+		filter.Sort = models.SortCreatedAtDesc
+	}
+	if page.Limit <= 0 {
+		page.Limit = models.DefaultPageLimit
+	}
+	if page.Limit > models.MaxPageLimit {
+		page.Limit = models.MaxPageLimit
+	}
+	limit := page.Limit
+	// fetch one extra row to learn whether another page exists
+	page.Limit++
+	todos, err := s.repo.ListTodosByUser(userID, filter, page)
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := len(todos) > limit
+	if hasMore {
+		todos = todos[:limit]
+	}
+	backward := page.Cursor != nil && page.Cursor.Backward
+	if backward {
+		for i, j := 0, len(todos)-1; i < j; i, j = i+1, j-1 {
+			todos[i], todos[j] = todos[j], todos[i]
+		}
+	}
+	if todos == nil {
+		todos = []models.Todo{}
+	}
+
+	result := &models.TodoPage{Todos: todos, HasMore: hasMore}
+	if len(todos) == 0 {
+		return result, nil
+	}
+	first, last := todos[0], todos[len(todos)-1]
+	if hasMore || backward {
+		result.NextCursor = repository.TodoCursor(filter.Sort, &last, false).Encode()
+	}
+	if (backward && hasMore) || (!backward && page.Cursor != nil) {
+		result.PrevCursor = repository.TodoCursor(filter.Sort, &first, true).Encode()
+	}
+	return result, nil
+}
+
+func (s *todoService) SearchTodosByUser(userID uuid.UUID, workspaceID *uuid.UUID, q string, limit int) ([]models.TodoSearchResult, error) {
+	if limit <= 0 {
+		limit = models.DefaultSearchLimit
+	}
+	if limit > models.MaxSearchLimit {
+		limit = models.MaxSearchLimit
+	}
+	return s.repo.SearchTodosByUser(userID, workspaceID, q, limit)
+}
+
 func (s *todoService) GetTodoByID(id uuid.UUID) (*models.Todo, error) {
 	return s.repo.GetTodoByID(id)
 }
 func (s *todoService) GetTodoByIDForUser(userID uuid.UUID, id uuid.UUID) (*models.Todo, error) {
-	return s.repo.GetTodoByIDForUser(userID, id)
+	ownerID, err := s.authorize(userID, id, models.ShareViewer)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetTodoByIDForUser(ownerID, id)
 }
 
 func (s *todoService) UpdateTodo(id uuid.UUID, req *models.UpdateTodoRequest) (*models.Todo, error) {
 	return s.repo.UpdateTodo(id, req)
 }
+
+// UpdateTodoForUser needs editor access; changing the project is left to
+// the owner, and moves the todo's subtasks along with it.
 func (s *todoService) UpdateTodoForUser(userID uuid.UUID, id uuid.UUID, req *models.UpdateTodoRequest) (*models.Todo, error) {
-	return s.repo.UpdateTodoForUser(userID, id, req)
+	need := models.ShareEditor
+	if req.ProjectID != nil {
+		need = models.ShareOwner
+	}
+	ownerID, err := s.authorize(userID, id, need)
+	if err != nil {
+		return nil, err
+	}
+	if req.ProjectID != nil {
+		existing, err := s.repo.GetTodoByIDForUser(ownerID, id)
+		if err != nil {
+			return nil, err
+		}
+		if existing.ParentID != nil {
+			return nil, models.ErrSubtaskProject
+		}
+		if err := s.checkProject(userID, ownerID, existing.WorkspaceID, req.ProjectID); err != nil {
+			return nil, err
+		}
+	}
+	if req.AssigneeID != nil {
+		if err := s.checkAssignee(*req.AssigneeID, id); err != nil {
+			return nil, err
+		}
+	}
+	if req.Recurrence != nil && *req.Recurrence != "" {
+		rule, err := parseRecurrence(*req.Recurrence)
+		if err != nil {
+			return nil, err
+		}
+		canonical := rule.String()
+		req.Recurrence = &canonical
+	}
+	return s.repo.UpdateTodoForUser(ownerID, id, req)
 }
 
 func (s *todoService) DeleteTodo(id uuid.UUID) error {
 	return s.repo.DeleteTodo(id)
 }
+
+// DeleteTodoForUser moves the todo to its owner's trash, so only the owner
+// may do it.
 func (s *todoService) DeleteTodoForUser(userID uuid.UUID, id uuid.UUID) error {
-	return s.repo.DeleteTodoForUser(userID, id)
+	ownerID, err := s.authorize(userID, id, models.ShareOwner)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteTodoForUser(ownerID, id)
 }
 
 func (s *todoService) ToggleTodoComplete(id uuid.UUID) (*models.Todo, error) {
 	return s.repo.ToggleTodoComplete(id)
 }
-func (s *todoService) ToggleTodoCompleteForUser(userID uuid.UUID, id uuid.UUID) (*models.Todo, error) {
-	return s.repo.ToggleTodoCompleteForUser(userID, id)
+
+// ToggleTodoCompleteForUser flips the todo's completed flag. Completing a
+// recurring todo spawns its next occurrence in the same transaction.
+func (s *todoService) ToggleTodoCompleteForUser(userID uuid.UUID, id uuid.UUID, cascade bool) (*models.Todo, error) {
+	ownerID, err := s.authorize(userID, id, models.ShareEditor)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ToggleTodoCompleteForUser(ownerID, id, cascade, func(todo *models.Todo) (*models.Todo, error) {
+		if todo.Recurrence == nil || todo.ParentID != nil {
+			return nil, nil
+		}
+		return nextOccurrence(todo, time.Now())
+	})
+}
+
+func parseRecurrence(s string) (*recurrence.Rule, error) {
+	rule, err := recurrence.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidRecurrence, err)
+	}
+	return rule, nil
+}
+
+// nextOccurrence builds the todo that follows a completed recurring todo.
+// The next due date comes from the rule applied to the old due date (or now
+// if there was none), skipping occurrences that are already in the past.
+// Skipped occurrences still use up COUNT, as RFC 5545 counts every date in
+// the series. It returns nil when the series has ended.
+func nextOccurrence(todo *models.Todo, now time.Time) (*models.Todo, error) {
+	rule, err := parseRecurrence(*todo.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	from := now
+	if todo.DueDate != nil {
+		from = *todo.DueDate
+	}
+	due, ok := rule.Next(from)
+	rule = rule.Advance()
+	for ok && !due.After(now) {
+		due, ok = rule.Next(due)
+		rule = rule.Advance()
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	nextRule := rule.String()
+	return &models.Todo{
+		Title:       todo.Title,
+		Description: todo.Description,
+		Priority:    todo.Priority,
+		DueDate:     &due,
+		UserID:      todo.UserID,
+		ProjectID:   todo.ProjectID,
+		WorkspaceID: todo.WorkspaceID,
+		AssigneeID:  todo.AssigneeID,
+		Recurrence:  &nextRule,
+		Tags:        todo.Tags,
+	}, nil
+}
+
+func (s *todoService) MoveTodoForUser(userID uuid.UUID, id uuid.UUID, projectID *uuid.UUID) (*models.Todo, error) {
+	ownerID, err := s.authorize(userID, id, models.ShareOwner)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.GetTodoByIDForUser(ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing.ParentID != nil {
+		return nil, models.ErrSubtaskProject
+	}
+	if err := s.checkProject(userID, ownerID, existing.WorkspaceID, projectID); err != nil {
+		return nil, err
+	}
+	return s.repo.MoveTodoForUser(ownerID, id, projectID)
+}
+
+// CreateSubtaskForUser adds a subtask at the end of the parent's list. Only
+// one level of nesting is allowed, and subtasks share their parent's project.
+func (s *todoService) CreateSubtaskForUser(userID uuid.UUID, parentID uuid.UUID, req *models.CreateTodoRequest) (*models.Todo, error) {
+	ownerID, err := s.authorize(userID, parentID, models.ShareEditor)
+	if err != nil {
+		return nil, err
+	}
+	parent, err := s.repo.GetTodoByIDForUser(ownerID, parentID)
+	if err != nil {
+		return nil, err
+	}
+	if parent.ParentID != nil {
+		return nil, models.ErrInvalidParent
+	}
+	if req.AssigneeID != nil {
+		// subtasks are visible to whoever can see the parent
+		if err := s.checkAssignee(*req.AssigneeID, parentID); err != nil {
+			return nil, err
+		}
+	}
+	todo := &models.Todo{
+		Title:       req.Title,
+		Description: req.Description,
+		Priority:    req.Priority,
+		DueDate:     req.DueDate,
+		Completed:   false,
+		UserID:      ownerID,
+		ProjectID:   parent.ProjectID,
+		WorkspaceID: parent.WorkspaceID,
+		AssigneeID:  req.AssigneeID,
+		ParentID:    &parent.ID,
+		Tags:        req.Tags,
+	}
+	if err := s.repo.CreateTodo(todo); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+func (s *todoService) GetSubtasksForUser(userID uuid.UUID, parentID uuid.UUID) ([]models.Todo, error) {
+	ownerID, err := s.authorize(userID, parentID, models.ShareViewer)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetSubtasksForUser(ownerID, parentID)
+}
+
+func (s *todoService) ReorderSubtasksForUser(userID uuid.UUID, parentID uuid.UUID, ids []uuid.UUID) ([]models.Todo, error) {
+	ownerID, err := s.authorize(userID, parentID, models.ShareEditor)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReorderSubtasksForUser(ownerID, parentID, ids); err != nil {
+		return nil, err
+	}
+	return s.repo.GetSubtasksForUser(ownerID, parentID)
 }
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+)
+
+func TestNextOccurrenceSkipsPastDates(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	twoDaysAgo := now.Add(-49 * time.Hour)
+	tests := []struct {
+		rule     string
+		due      *time.Time
+		wantDue  time.Time // zero when the series is over
+		wantRule string
+	}{
+		{"FREQ=DAILY", &twoDaysAgo, now.Add(23 * time.Hour), "FREQ=DAILY"},
+		{"FREQ=DAILY;COUNT=5", &twoDaysAgo, now.Add(23 * time.Hour), "FREQ=DAILY;COUNT=2"},
+		{"FREQ=DAILY;COUNT=4", &twoDaysAgo, now.Add(23 * time.Hour), "FREQ=DAILY;COUNT=1"},
+		// the two skipped dates use up the rest of the series
+		{"FREQ=DAILY;COUNT=3", &twoDaysAgo, time.Time{}, ""},
+		{"FREQ=DAILY;COUNT=2", nil, now.Add(24 * time.Hour), "FREQ=DAILY;COUNT=1"},
+	}
+	for _, tt := range tests {
+		rule := tt.rule
+		todo := &models.Todo{Title: "Water plants", Priority: models.PriorityHigh, DueDate: tt.due, Recurrence: &rule}
+		next, err := nextOccurrence(todo, now)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.rule, err)
+		}
+		if tt.wantDue.IsZero() {
+			if next != nil {
+				t.Errorf("%s: next due %s, want the series to end", tt.rule, next.DueDate)
+			}
+			continue
+		}
+		if next == nil {
+			t.Errorf("%s: series ended, want next due %s", tt.rule, tt.wantDue)
+			continue
+		}
+		if !next.DueDate.Equal(tt.wantDue) || *next.Recurrence != tt.wantRule {
+			t.Errorf("%s: next = %s %q, want %s %q", tt.rule, next.DueDate, *next.Recurrence, tt.wantDue, tt.wantRule)
+		}
+		if next.Priority != todo.Priority {
+			t.Errorf("%s: priority = %v, want %v", tt.rule, next.Priority, todo.Priority)
+		}
+	}
+}
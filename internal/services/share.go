@@ -0,0 +1,206 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/danieldzansi/todo-api/internal/mailer"
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+type ShareService interface {
+	ShareTodo(userID uuid.UUID, todoID uuid.UUID, req *models.CreateShareRequest) (*models.Share, error)
+	GetTodoShares(userID uuid.UUID, todoID uuid.UUID) ([]models.Share, error)
+	UnshareTodo(userID uuid.UUID, todoID uuid.UUID, shareUserID uuid.UUID) error
+	ShareProject(userID uuid.UUID, projectID uuid.UUID, req *models.CreateShareRequest) (*models.Share, error)
+	GetProjectShares(userID uuid.UUID, projectID uuid.UUID) ([]models.Share, error)
+	UnshareProject(userID uuid.UUID, projectID uuid.UUID, shareUserID uuid.UUID) error
+	GetInvitations(userID uuid.UUID) ([]models.Share, error)
+	AcceptInvitation(userID uuid.UUID, id uuid.UUID) (*models.Share, error)
+	DeclineInvitation(userID uuid.UUID, id uuid.UUID) error
+	GetSharedTodos(userID uuid.UUID, projectID *uuid.UUID) ([]models.SharedTodo, error)
+	GetSharedProjects(userID uuid.UUID) ([]models.SharedProject, error)
+}
+
+type shareService struct {
+	repo     repository.ShareRepository
+	todos    repository.TodoRepository
+	projects repository.ProjectRepository
+	users    repository.UserRepository
+	mail     mailer.Mailer
+}
+
+func NewShareService(r repository.ShareRepository, todos repository.TodoRepository, projects repository.ProjectRepository, users repository.UserRepository, mail mailer.Mailer) ShareService {
+	return &shareService{repo: r, todos: todos, projects: projects, users: users, mail: mail}
+}
+
+// todoRole returns the user's role on a todo they can see.
+func (s *shareService) todoRole(userID uuid.UUID, todoID uuid.UUID) (models.ShareRole, error) {
+	_, role, err := s.todos.GetTodoAccess(userID, todoID)
+	return role, err
+}
+
+func (s *shareService) projectRole(userID uuid.UUID, projectID uuid.UUID) (models.ShareRole, error) {
+	_, role, err := s.projects.GetProjectAccess(userID, projectID)
+	return role, err
+}
+
+// invite records a pending share for the user with req.Email and mails
+// them about it. title names what was shared in the mail. An address with
+// no account gets the same answer as one with an account, a pending share
+// that is never stored, so sharing can't be used to find out who has
+// signed up. For the same reason the answer echoes the address as given
+// and leaves out the invitee's name, and the mail goes out in the
+// background.
+func (s *shareService) invite(inviterID uuid.UUID, share *models.Share, req *models.CreateShareRequest, title string) (*models.Share, error) {
+	if req.Role != models.ShareViewer && req.Role != models.ShareEditor {
+		return nil, models.ErrInvalidShareRole
+	}
+	inviter, err := s.users.GetUserByID(inviterID)
+	if err != nil {
+		return nil, err
+	}
+	share.Role = req.Role
+	share.InvitedBy = &inviterID
+	share.UserEmail = strings.TrimSpace(req.Email)
+	share.Title = title
+	share.InvitedByName = inviter.Name
+
+	invitee, err := s.users.GetUserByEmail(share.UserEmail)
+	if err == models.ErrUserNotFound {
+		share.ID = uuid.New()
+		share.UserID = uuid.New()
+		share.Status = models.SharePending
+		share.CreatedAt = time.Now()
+		return share, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if invitee.ID == inviterID {
+		return nil, models.ErrShareWithSelf
+	}
+	share.UserID = invitee.ID
+	if err := s.repo.UpsertShare(share); err != nil {
+		return nil, err
+	}
+
+	if share.Status == models.SharePending {
+		msg := mailer.Message{
+			To:      invitee.Email,
+			Subject: fmt.Sprintf("%s shared %q with you", inviter.Name, title),
+			Body: fmt.Sprintf("Hi %s,\n\n%s invited you to %q as %s. "+
+				"To accept, send POST %s/api/v1/invitations/%s/accept while logged in, "+
+				"or decline with POST %s/api/v1/invitations/%s/decline.",
+				invitee.Name, inviter.Name, title, share.Role, AppURL(), share.ID, AppURL(), share.ID),
+		}
+		go func(id uuid.UUID) {
+			if err := s.mail.Send(msg); err != nil {
+				log.Printf("Failed to send share invitation %s: %v", id, err)
+			}
+		}(share.ID)
+	}
+	return share, nil
+}
+
+// ShareTodo invites another user to the todo. Only the owner can share,
+// and only personal todos; workspace todos go to the workspace's members.
+func (s *shareService) ShareTodo(userID uuid.UUID, todoID uuid.UUID, req *models.CreateShareRequest) (*models.Share, error) {
+	ownerID, role, err := s.todos.GetTodoAccess(userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+	if role != models.ShareOwner {
+		return nil, models.ErrForbidden
+	}
+	todo, err := s.todos.GetTodoByIDForUser(ownerID, todoID)
+	if err != nil {
+		return nil, err
+	}
+	if todo.WorkspaceID != nil {
+		return nil, models.ErrShareWorkspaceItem
+	}
+	return s.invite(userID, &models.Share{TodoID: &todoID}, req, todo.Title)
+}
+
+// GetTodoShares lists who a todo is shared with, to anyone who can see it.
+func (s *shareService) GetTodoShares(userID uuid.UUID, todoID uuid.UUID) ([]models.Share, error) {
+	if _, err := s.todoRole(userID, todoID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetTodoShares(todoID)
+}
+
+// UnshareTodo removes shareUserID's access. The owner can remove anyone;
+// everyone else can only remove themselves.
+func (s *shareService) UnshareTodo(userID uuid.UUID, todoID uuid.UUID, shareUserID uuid.UUID) error {
+	role, err := s.todoRole(userID, todoID)
+	// a pending invitee can't see the todo yet but may still leave
+	if err != nil && !(err == models.ErrTodoNotFound && userID == shareUserID) {
+		return err
+	}
+	if role != models.ShareOwner && userID != shareUserID {
+		return models.ErrForbidden
+	}
+	return s.repo.DeleteTodoShare(todoID, shareUserID)
+}
+
+func (s *shareService) ShareProject(userID uuid.UUID, projectID uuid.UUID, req *models.CreateShareRequest) (*models.Share, error) {
+	ownerID, role, err := s.projects.GetProjectAccess(userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if role != models.ShareOwner {
+		return nil, models.ErrForbidden
+	}
+	project, err := s.projects.GetProjectByIDForUser(ownerID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project.WorkspaceID != nil {
+		return nil, models.ErrShareWorkspaceItem
+	}
+	return s.invite(userID, &models.Share{ProjectID: &projectID}, req, project.Name)
+}
+
+func (s *shareService) GetProjectShares(userID uuid.UUID, projectID uuid.UUID) ([]models.Share, error) {
+	if _, err := s.projectRole(userID, projectID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetProjectShares(projectID)
+}
+
+func (s *shareService) UnshareProject(userID uuid.UUID, projectID uuid.UUID, shareUserID uuid.UUID) error {
+	role, err := s.projectRole(userID, projectID)
+	if err != nil && !(err == models.ErrProjectNotFound && userID == shareUserID) {
+		return err
+	}
+	if role != models.ShareOwner && userID != shareUserID {
+		return models.ErrForbidden
+	}
+	return s.repo.DeleteProjectShare(projectID, shareUserID)
+}
+
+func (s *shareService) GetInvitations(userID uuid.UUID) ([]models.Share, error) {
+	return s.repo.GetInvitations(userID)
+}
+
+func (s *shareService) AcceptInvitation(userID uuid.UUID, id uuid.UUID) (*models.Share, error) {
+	return s.repo.AcceptInvitation(userID, id)
+}
+
+func (s *shareService) DeclineInvitation(userID uuid.UUID, id uuid.UUID) error {
+	return s.repo.DeclineInvitation(userID, id)
+}
+
+func (s *shareService) GetSharedTodos(userID uuid.UUID, projectID *uuid.UUID) ([]models.SharedTodo, error) {
+	return s.todos.GetSharedTodos(userID, projectID)
+}
+
+func (s *shareService) GetSharedProjects(userID uuid.UUID) ([]models.SharedProject, error) {
+	return s.projects.GetSharedProjects(userID)
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"strings"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+type TagService interface {
+	CreateTagForUser(userID uuid.UUID, req *models.CreateTagRequest) (*models.Tag, error)
+	GetTagsByUser(userID uuid.UUID) ([]models.Tag, error)
+	GetTagByIDForUser(userID uuid.UUID, id uuid.UUID) (*models.Tag, error)
+	UpdateTagForUser(userID uuid.UUID, id uuid.UUID, req *models.UpdateTagRequest) (*models.Tag, error)
+	DeleteTagForUser(userID uuid.UUID, id uuid.UUID) error
+}
+
+type tagService struct {
+	repo repository.TagRepository
+}
+
+func NewTagService(r repository.TagRepository) TagService {
+	return &tagService{repo: r}
+}
+
+func (s *tagService) CreateTagForUser(userID uuid.UUID, req *models.CreateTagRequest) (*models.Tag, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, models.ErrInvalidTagName
+	}
+	tag := &models.Tag{
+		UserID: userID,
+		Name:   name,
+		Color:  req.Color,
+	}
+	if err := s.repo.CreateTag(tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (s *tagService) GetTagsByUser(userID uuid.UUID) ([]models.Tag, error) {
+	return s.repo.GetTagsByUser(userID)
+}
+
+func (s *tagService) GetTagByIDForUser(userID uuid.UUID, id uuid.UUID) (*models.Tag, error) {
+	return s.repo.GetTagByIDForUser(userID, id)
+}
+
+func (s *tagService) UpdateTagForUser(userID uuid.UUID, id uuid.UUID, req *models.UpdateTagRequest) (*models.Tag, error) {
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, models.ErrInvalidTagName
+		}
+		req.Name = &name
+	}
+	return s.repo.UpdateTagForUser(userID, id, req)
+}
+
+func (s *tagService) DeleteTagForUser(userID uuid.UUID, id uuid.UUID) error {
+	return s.repo.DeleteTagForUser(userID, id)
+}
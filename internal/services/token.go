@@ -0,0 +1,342 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// durationFromEnv reads a time.ParseDuration value such as "15m", falling
+// back to def when the variable is unset or invalid.
+func durationFromEnv(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s %q, using %s", key, v, def)
+		return def
+	}
+	return d
+}
+
+// newOpaqueToken returns a random URL-safe token and the SHA-256 hash under
+// which it is stored.
+func newOpaqueToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// roleNames returns the names of roles.
+func roleNames(roles []models.Role) []string {
+	names := make([]string, len(roles))
+	for i, r := range roles {
+		names[i] = r.Name
+	}
+	return names
+}
+
+// rolePermissions returns the union of the permissions granted by roles.
+func rolePermissions(roles []models.Role) []string {
+	var perms []string
+	for _, r := range roles {
+		perms = append(perms, r.Permissions...)
+	}
+	return uniqueStrings(perms)
+}
+
+// issueAccessToken signs an access token carrying the user's current roles
+// and permissions, so role checks don't need a database lookup.
+func (s *AuthServiceImpl) issueAccessToken(user *models.User) (string, time.Time, error) {
+	roles, err := s.roles.GetUserRoles(user.ID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(durationFromEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL))
+	signed, err := s.keys.Sign(jwt.MapClaims{
+		"iss":            AppURL(),
+		"sub":            user.ID.String(),
+		"email":          user.Email,
+		"email_verified": user.EmailVerifiedAt != nil,
+		"roles":          roleNames(roles),
+		"perms":          rolePermissions(roles),
+		"jti":            uuid.NewString(),
+		"exp":            expiresAt.Unix(),
+		"iat":            now.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// issueTokens creates an access token and a refresh token in the given
+// family. A fresh login starts a new family; refreshes stay in theirs.
+func (s *AuthServiceImpl) issueTokens(user *models.User, familyID uuid.UUID) (*models.LoginResponse, error) {
+	access, expiresAt, err := s.issueAccessToken(user)
+	if err != nil {
+		return nil, err
+	}
+	refresh, stored, err := newRefreshToken(user.ID, familyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokens.CreateRefreshToken(stored); err != nil {
+		return nil, err
+	}
+	return loginResponse(user, access, expiresAt, refresh, stored.ExpiresAt), nil
+}
+
+// IssueTokens logs user in without a password, for sign-in flows that
+// authenticated them some other way such as OIDC. Users with two-factor
+// authentication get a login challenge instead, as with Login, so the
+// other sign-in doesn't bypass their second factor.
+func (s *AuthServiceImpl) IssueTokens(user *models.User) (*models.LoginResponse, error) {
+	if user.TOTPEnabledAt != nil {
+		return s.startLoginChallenge(user)
+	}
+	return s.issueTokens(user, uuid.New())
+}
+
+func newRefreshToken(userID, familyID uuid.UUID) (string, *models.RefreshToken, error) {
+	token, hash, err := newOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+	return token, &models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(durationFromEnv("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)),
+	}, nil
+}
+
+func loginResponse(user *models.User, access string, expiresAt time.Time, refresh string, refreshExpiresAt time.Time) *models.LoginResponse {
+	safeUser := *user
+	safeUser.Password = ""
+	return &models.LoginResponse{
+		Token:            access,
+		ExpiresAt:        &expiresAt,
+		RefreshToken:     refresh,
+		RefreshExpiresAt: &refreshExpiresAt,
+		User:             &safeUser,
+	}
+}
+
+// Refresh trades a refresh token for a new access token and a new refresh
+// token. Presenting a token that was already rotated means it leaked, so the
+// whole family is revoked and every session from that login must sign in
+// again.
+func (s *AuthServiceImpl) Refresh(req *models.RefreshRequest) (*models.LoginResponse, error) {
+	current, err := s.tokens.GetRefreshTokenByHash(hashToken(req.RefreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if current.RevokedAt != nil {
+		if err := s.tokens.RevokeRefreshTokenFamily(current.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, models.ErrRefreshTokenReused
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, models.ErrInvalidRefreshToken
+	}
+
+	user, err := s.repo.GetUserByID(current.UserID)
+	if err != nil {
+		return nil, err
+	}
+	access, expiresAt, err := s.issueAccessToken(user)
+	if err != nil {
+		return nil, err
+	}
+	refresh, next, err := newRefreshToken(user.ID, current.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokens.RotateRefreshToken(current.ID, next); err != nil {
+		if err == models.ErrRefreshTokenReused {
+			if err := s.tokens.RevokeRefreshTokenFamily(current.FamilyID); err != nil {
+				return nil, err
+			}
+			return nil, models.ErrRefreshTokenReused
+		}
+		return nil, err
+	}
+	return loginResponse(user, access, expiresAt, refresh, next.ExpiresAt), nil
+}
+
+// Authenticate verifies an access token and checks that it hasn't been
+// revoked, either on its own or by logging out all sessions. Personal
+// access tokens are accepted too.
+func (s *AuthServiceImpl) Authenticate(tokenString string) (*models.AccessClaims, error) {
+	if strings.HasPrefix(tokenString, models.PersonalTokenPrefix) {
+		return s.authenticatePersonalToken(tokenString)
+	}
+	token, err := jwt.Parse(tokenString, s.keys.Keyfunc,
+		jwt.WithValidMethods(s.keys.Methods()), jwt.WithExpirationRequired(), jwt.WithIssuedAt())
+	if err != nil || !token.Valid {
+		return nil, models.ErrInvalidToken
+	}
+	claims, err := accessClaims(token.Claims)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := s.isRevoked(claims)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, models.ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+func accessClaims(c jwt.Claims) (*models.AccessClaims, error) {
+	mc, ok := c.(jwt.MapClaims)
+	if !ok {
+		return nil, models.ErrInvalidToken
+	}
+	sub, _ := mc["sub"].(string)
+	userID, err := uuid.Parse(sub)
+	if err != nil {
+		return nil, models.ErrInvalidToken
+	}
+	jti, _ := mc["jti"].(string)
+	tokenID, err := uuid.Parse(jti)
+	if err != nil {
+		return nil, models.ErrInvalidToken
+	}
+	iat, err := mc.GetIssuedAt()
+	if err != nil || iat == nil {
+		return nil, models.ErrInvalidToken
+	}
+	exp, err := mc.GetExpirationTime()
+	if err != nil || exp == nil {
+		return nil, models.ErrInvalidToken
+	}
+	email, _ := mc["email"].(string)
+	verified, _ := mc["email_verified"].(bool)
+	return &models.AccessClaims{
+		UserID:        userID,
+		Email:         email,
+		EmailVerified: verified,
+		Roles:         stringsClaim(mc["roles"]),
+		Permissions:   stringsClaim(mc["perms"]),
+		TokenID:       tokenID,
+		IssuedAt:      iat.Time,
+		ExpiresAt:     exp.Time,
+	}, nil
+}
+
+// stringsClaim reads a JSON array of strings from a decoded claim.
+func stringsClaim(v interface{}) []string {
+	items, _ := v.([]interface{})
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func (s *AuthServiceImpl) isRevoked(claims *models.AccessClaims) (bool, error) {
+	cut, err := s.issuedBeforeCutoff(claims.UserID, claims.IssuedAt)
+	if err != nil || cut {
+		return cut, err
+	}
+
+	revoked, ok := s.revoked.token(claims.TokenID)
+	if !ok {
+		revoked, err = s.tokens.IsAccessTokenRevoked(claims.TokenID)
+		if err != nil {
+			return false, err
+		}
+		s.revoked.setToken(claims.TokenID, revoked, claims.ExpiresAt)
+	}
+	return revoked, nil
+}
+
+// issuedBeforeCutoff reports whether a credential issued at issuedAt
+// predates the user's tokens_valid_after cutoff, which password resets and
+// changes move forward. Deleted users count as cut off.
+func (s *AuthServiceImpl) issuedBeforeCutoff(userID uuid.UUID, issuedAt time.Time) (bool, error) {
+	after, ok := s.revoked.cutoff(userID)
+	if !ok {
+		var err error
+		after, err = s.tokens.GetTokensValidAfter(userID)
+		if err != nil {
+			if err == models.ErrUserNotFound {
+				return true, nil
+			}
+			return false, err
+		}
+		s.revoked.setCutoff(userID, after)
+	}
+	return after != nil && issuedAt.Before(*after), nil
+}
+
+// Logout revokes the access token the request was made with and, if one is
+// given, the refresh token family it was issued alongside.
+func (s *AuthServiceImpl) Logout(claims *models.AccessClaims, req *models.LogoutRequest) error {
+	if err := s.tokens.RevokeAccessToken(claims.TokenID, claims.UserID, claims.ExpiresAt); err != nil {
+		return err
+	}
+	s.revoked.setToken(claims.TokenID, true, claims.ExpiresAt)
+
+	if req.RefreshToken == "" {
+		return nil
+	}
+	refresh, err := s.tokens.GetRefreshTokenByHash(hashToken(req.RefreshToken))
+	if err != nil {
+		if err == models.ErrInvalidRefreshToken {
+			return nil
+		}
+		return err
+	}
+	if refresh.UserID != claims.UserID {
+		return nil
+	}
+	return s.tokens.RevokeRefreshTokenFamily(refresh.FamilyID)
+}
+
+// LogoutAll ends every session the user has: access tokens issued so far
+// are rejected, all refresh tokens are revoked, and personal access tokens
+// created so far stop working.
+func (s *AuthServiceImpl) LogoutAll(userID uuid.UUID) error {
+	// iat has whole-second precision; truncating keeps tokens issued later
+	// in the same second, e.g. by logging straight back in, valid
+	at := time.Now().Truncate(time.Second)
+	if err := s.tokens.RevokeAllForUser(userID, at); err != nil {
+		return err
+	}
+	s.revoked.setCutoff(userID, &at)
+	return nil
+}
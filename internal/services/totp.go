@@ -0,0 +1,211 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"os"
+	"strings"
+	"time"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/totp"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	defaultLoginChallengeTTL = 5 * time.Minute
+	recoveryCodeCount        = 10
+)
+
+func totpIssuer() string {
+	if v := os.Getenv("TOTP_ISSUER"); v != "" {
+		return v
+	}
+	return "Todo API"
+}
+
+// EnrollTOTP starts two-factor enrollment. The returned secret has no
+// effect until ConfirmTOTP is called with a code generated from it.
+func (s *AuthServiceImpl) EnrollTOTP(userID uuid.UUID) (*models.TOTPEnrollment, error) {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, models.ErrTOTPAlreadyEnabled
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.totp.SetPendingTOTPSecret(userID, secret); err != nil {
+		return nil, err
+	}
+	return &models.TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(totpIssuer(), user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP turns two-factor authentication on and returns the user's
+// recovery codes.
+func (s *AuthServiceImpl) ConfirmTOTP(userID uuid.UUID, req *models.TOTPCodeRequest) (*models.RecoveryCodes, error) {
+	st, err := s.totp.GetTOTPState(userID)
+	if err != nil {
+		return nil, err
+	}
+	if st.EnabledAt != nil {
+		return nil, models.ErrTOTPAlreadyEnabled
+	}
+	if st.Secret == nil {
+		return nil, models.ErrTOTPNotEnrolled
+	}
+	step, ok := totp.Validate(*st.Secret, req.Code, time.Now())
+	if !ok {
+		return nil, models.ErrInvalidTOTPCode
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.totp.EnableTOTP(userID, step, hashes); err != nil {
+		return nil, err
+	}
+	return &models.RecoveryCodes{Codes: codes}, nil
+}
+
+// DisableTOTP turns two-factor authentication off. It needs both the
+// password and a current code (or recovery code).
+func (s *AuthServiceImpl) DisableTOTP(userID uuid.UUID, req *models.DisableTOTPRequest) error {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.TOTPEnabledAt == nil {
+		return models.ErrTOTPNotEnabled
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)) != nil {
+		return models.ErrInvalidCredentials
+	}
+	if err := s.checkSecondFactor(userID, req.Code, true); err != nil {
+		return err
+	}
+	return s.totp.DisableTOTP(userID)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes, used or not. It
+// needs a TOTP code; a recovery code won't do.
+func (s *AuthServiceImpl) RegenerateRecoveryCodes(userID uuid.UUID, req *models.TOTPCodeRequest) (*models.RecoveryCodes, error) {
+	if err := s.checkSecondFactor(userID, req.Code, false); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.totp.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return &models.RecoveryCodes{Codes: codes}, nil
+}
+
+// startLoginChallenge is the first half of a two-factor login: the password
+// was right, and the client gets a challenge token to present with a code.
+func (s *AuthServiceImpl) startLoginChallenge(user *models.User) (*models.LoginResponse, error) {
+	token, expiresAt, err := s.issueUserToken(user.ID, models.PurposeLoginChallenge, durationFromEnv("LOGIN_CHALLENGE_TTL", defaultLoginChallengeTTL))
+	if err != nil {
+		return nil, err
+	}
+	return &models.LoginResponse{
+		TwoFactorRequired:  true,
+		ChallengeToken:     token,
+		ChallengeExpiresAt: &expiresAt,
+	}, nil
+}
+
+// VerifyLogin is the second half of a two-factor login. A wrong code leaves
+// the challenge usable until it expires.
+func (s *AuthServiceImpl) VerifyLogin(req *models.VerifyLoginRequest) (*models.LoginResponse, error) {
+	hash := hashToken(req.ChallengeToken)
+	challenge, err := s.tokens.GetUserToken(models.PurposeLoginChallenge, hash)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.repo.GetUserByID(challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// wrong codes count towards the same lockout as wrong passwords
+	email := normalizeEmail(user.Email)
+	if err := s.checkLoginThrottle(email, req.ClientIP); err != nil {
+		return nil, s.loginFailed(email, &user.ID, req.ClientIP, models.LoginFailLocked, err)
+	}
+	if err := s.checkSecondFactor(user.ID, req.Code, true); err != nil {
+		if err == models.ErrInvalidTOTPCode {
+			return nil, s.loginFailed(email, &user.ID, req.ClientIP, models.LoginFailBadCode, err)
+		}
+		return nil, err
+	}
+	if _, err := s.tokens.ConsumeUserToken(models.PurposeLoginChallenge, hash); err != nil {
+		return nil, err
+	}
+	if err := s.recordLoginAttempt(email, &user.ID, req.ClientIP, ""); err != nil {
+		return nil, err
+	}
+	return s.issueTokens(user, uuid.New())
+}
+
+// checkSecondFactor accepts a TOTP code that hasn't been used before or,
+// if allowRecovery is set, an unused recovery code.
+func (s *AuthServiceImpl) checkSecondFactor(userID uuid.UUID, code string, allowRecovery bool) error {
+	st, err := s.totp.GetTOTPState(userID)
+	if err != nil {
+		return err
+	}
+	if st.EnabledAt == nil || st.Secret == nil {
+		return models.ErrTOTPNotEnabled
+	}
+	if step, ok := totp.Validate(*st.Secret, code, time.Now()); ok {
+		fresh, err := s.totp.UseTOTPStep(userID, step)
+		if err != nil {
+			return err
+		}
+		if fresh {
+			return nil
+		}
+		return models.ErrInvalidTOTPCode
+	}
+	if !allowRecovery {
+		return models.ErrInvalidTOTPCode
+	}
+	used, err := s.totp.UseRecoveryCode(userID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return models.ErrInvalidTOTPCode
+	}
+	return nil
+}
+
+// newRecoveryCodes returns codes formatted for display, e.g.
+// "k3vq7-m2xpa", and the hashes to store.
+func newRecoveryCodes() (codes []string, hashes []string, err error) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(enc.EncodeToString(b))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+func (s *todoService) GetTrashByUser(userID uuid.UUID, workspaceID *uuid.UUID) ([]models.Todo, error) {
+	return s.repo.GetTrashByUser(userID, workspaceID)
+}
+
+func (s *todoService) RestoreTodoForUser(userID uuid.UUID, workspaceID *uuid.UUID, id uuid.UUID) (*models.Todo, error) {
+	return s.repo.RestoreTodoForUser(userID, workspaceID, id)
+}
+
+func (s *todoService) PurgeTodoForUser(userID uuid.UUID, workspaceID *uuid.UUID, id uuid.UUID) error {
+	return s.repo.PurgeTodoForUser(userID, workspaceID, id)
+}
+
+func (s *todoService) EmptyTrashForUser(userID uuid.UUID, workspaceID *uuid.UUID) (int64, error) {
+	return s.repo.EmptyTrashForUser(userID, workspaceID)
+}
+
+// TrashPurger periodically deletes todos that have been in the trash for
+// longer than the retention period.
+type TrashPurger struct {
+	repo      repository.TodoRepository
+	retention time.Duration
+	interval  time.Duration
+	done      chan struct{}
+}
+
+func NewTrashPurger(repo repository.TodoRepository, retention, interval time.Duration) *TrashPurger {
+	return &TrashPurger{repo: repo, retention: retention, interval: interval, done: make(chan struct{})}
+}
+
+// Start runs a purge right away and then once per interval until Stop is
+// called.
+func (p *TrashPurger) Start() {
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			p.purge()
+			select {
+			case <-ticker.C:
+			case <-p.done:
+				return
+			}
+		}
+	}()
+}
+
+func (p *TrashPurger) Stop() {
+	close(p.done)
+}
+
+func (p *TrashPurger) purge() {
+	n, err := p.repo.PurgeTrashedBefore(time.Now().Add(-p.retention))
+	if err != nil {
+		log.Println("Failed to purge trash:", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("Purged %d todos from trash", n)
+	}
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/danieldzansi/todo-api/internal/mailer"
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+type WorkspaceService interface {
+	CreateWorkspace(userID uuid.UUID, req *models.CreateWorkspaceRequest) (*models.Workspace, error)
+	GetWorkspaces(userID uuid.UUID) ([]models.Workspace, error)
+	GetWorkspace(userID uuid.UUID, id uuid.UUID) (*models.Workspace, error)
+	UpdateWorkspace(userID uuid.UUID, id uuid.UUID, req *models.UpdateWorkspaceRequest) (*models.Workspace, error)
+	DeleteWorkspace(userID uuid.UUID, id uuid.UUID) error
+	GetMembers(userID uuid.UUID, id uuid.UUID) ([]models.WorkspaceMembership, error)
+	AddMember(userID uuid.UUID, id uuid.UUID, req *models.AddMemberRequest) (*models.WorkspaceMembership, error)
+	UpdateMember(userID uuid.UUID, id uuid.UUID, memberID uuid.UUID, req *models.UpdateMemberRequest) (*models.WorkspaceMembership, error)
+	RemoveMember(userID uuid.UUID, id uuid.UUID, memberID uuid.UUID) error
+	Membership(userID uuid.UUID, id uuid.UUID) (models.WorkspaceRole, error)
+}
+
+type workspaceService struct {
+	repo  repository.WorkspaceRepository
+	users repository.UserRepository
+	mail  mailer.Mailer
+}
+
+func NewWorkspaceService(r repository.WorkspaceRepository, users repository.UserRepository, mail mailer.Mailer) WorkspaceService {
+	return &workspaceService{repo: r, users: users, mail: mail}
+}
+
+// sameWorkspace reports whether a and b name the same workspace, with nil
+// standing for personal space.
+func sameWorkspace(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func workspaceName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", models.ErrInvalidWorkspaceName
+	}
+	return name, nil
+}
+
+// authorize checks that the user holds at least need in the workspace and
+// returns their role. Non-members get ErrWorkspaceNotFound.
+func (s *workspaceService) authorize(userID uuid.UUID, id uuid.UUID, need models.WorkspaceRole) (models.WorkspaceRole, error) {
+	role, err := s.repo.GetMemberRole(userID, id)
+	if err != nil {
+		return "", err
+	}
+	if !role.Allows(need) {
+		return "", models.ErrForbidden
+	}
+	return role, nil
+}
+
+func (s *workspaceService) CreateWorkspace(userID uuid.UUID, req *models.CreateWorkspaceRequest) (*models.Workspace, error) {
+	name, err := workspaceName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	workspace := &models.Workspace{Name: name, CreatedBy: &userID}
+	if err := s.repo.CreateWorkspace(workspace); err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
+
+func (s *workspaceService) GetWorkspaces(userID uuid.UUID) ([]models.Workspace, error) {
+	return s.repo.GetWorkspacesByUser(userID)
+}
+
+func (s *workspaceService) GetWorkspace(userID uuid.UUID, id uuid.UUID) (*models.Workspace, error) {
+	return s.repo.GetWorkspaceForMember(userID, id)
+}
+
+// UpdateWorkspace renames the workspace; owners and admins may do it.
+func (s *workspaceService) UpdateWorkspace(userID uuid.UUID, id uuid.UUID, req *models.UpdateWorkspaceRequest) (*models.Workspace, error) {
+	if _, err := s.authorize(userID, id, models.WorkspaceAdmin); err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		name, err := workspaceName(*req.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.repo.UpdateWorkspace(id, name); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.GetWorkspaceForMember(userID, id)
+}
+
+// DeleteWorkspace removes the workspace with everything in it. Only owners
+// may do it.
+func (s *workspaceService) DeleteWorkspace(userID uuid.UUID, id uuid.UUID) error {
+	if _, err := s.authorize(userID, id, models.WorkspaceOwner); err != nil {
+		return err
+	}
+	return s.repo.DeleteWorkspace(id)
+}
+
+func (s *workspaceService) GetMembers(userID uuid.UUID, id uuid.UUID) ([]models.WorkspaceMembership, error) {
+	if _, err := s.authorize(userID, id, models.WorkspaceMember); err != nil {
+		return nil, err
+	}
+	return s.repo.GetMembers(id)
+}
+
+// AddMember adds the user with req.Email to the workspace, as a member
+// unless another role is given. Owners and admins can add people; only
+// owners can add another owner. An address with no account gets the same
+// answer as one with an account, echoing the address as given and without
+// a name, so adding members can't be used to find out who has signed up.
+func (s *workspaceService) AddMember(userID uuid.UUID, id uuid.UUID, req *models.AddMemberRequest) (*models.WorkspaceMembership, error) {
+	if req.Role == "" {
+		req.Role = models.WorkspaceMember
+	}
+	if !req.Role.Valid() {
+		return nil, models.ErrInvalidWorkspaceRole
+	}
+	callerRole, err := s.authorize(userID, id, models.WorkspaceAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if req.Role == models.WorkspaceOwner && callerRole != models.WorkspaceOwner {
+		return nil, models.ErrForbidden
+	}
+	email := strings.TrimSpace(req.Email)
+	user, err := s.users.GetUserByEmail(email)
+	if err == models.ErrUserNotFound {
+		return &models.WorkspaceMembership{WorkspaceID: id, UserID: uuid.New(), Role: req.Role, CreatedAt: time.Now(), Email: email}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	member := &models.WorkspaceMembership{WorkspaceID: id, UserID: user.ID, Role: req.Role, Email: email}
+	if err := s.repo.AddMember(member); err != nil {
+		return nil, err
+	}
+
+	workspace, err := s.repo.GetWorkspaceForMember(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: fmt.Sprintf("You were added to %q", workspace.Name),
+		Body: fmt.Sprintf("Hi %s,\n\nYou were added to the workspace %q as %s. "+
+			"Send X-Workspace-ID: %s with your requests to %s/api/v1/todos to work with its todos.",
+			user.Name, workspace.Name, member.Role, id, AppURL()),
+	}
+	go func() {
+		if err := s.mail.Send(msg); err != nil {
+			log.Printf("Failed to send workspace notice to %s: %v", user.ID, err)
+		}
+	}()
+	return member, nil
+}
+
+// UpdateMember changes a member's role. Owners and admins can change
+// roles, but only owners can make someone an owner or change an owner's
+// role.
+func (s *workspaceService) UpdateMember(userID uuid.UUID, id uuid.UUID, memberID uuid.UUID, req *models.UpdateMemberRequest) (*models.WorkspaceMembership, error) {
+	if !req.Role.Valid() {
+		return nil, models.ErrInvalidWorkspaceRole
+	}
+	callerRole, err := s.authorize(userID, id, models.WorkspaceAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkOwnerChange(callerRole, id, memberID, req.Role); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetMemberRole(id, memberID, req.Role); err != nil {
+		return nil, err
+	}
+	members, err := s.repo.GetMembers(id)
+	if err != nil {
+		return nil, err
+	}
+	for i := range members {
+		if members[i].UserID == memberID {
+			return &members[i], nil
+		}
+	}
+	return nil, models.ErrMemberNotFound
+}
+
+// RemoveMember takes memberID out of the workspace. Everyone may leave;
+// owners and admins can remove others, but only owners can remove an
+// owner.
+func (s *workspaceService) RemoveMember(userID uuid.UUID, id uuid.UUID, memberID uuid.UUID) error {
+	if userID != memberID {
+		callerRole, err := s.authorize(userID, id, models.WorkspaceAdmin)
+		if err != nil {
+			return err
+		}
+		if err := s.checkOwnerChange(callerRole, id, memberID, ""); err != nil {
+			return err
+		}
+	} else if _, err := s.authorize(userID, id, models.WorkspaceMember); err != nil {
+		return err
+	}
+	return s.repo.RemoveMember(id, memberID)
+}
+
+// checkOwnerChange refuses to let anyone but an owner touch an owner's
+// membership or hand out ownership.
+func (s *workspaceService) checkOwnerChange(callerRole models.WorkspaceRole, id uuid.UUID, memberID uuid.UUID, newRole models.WorkspaceRole) error {
+	if callerRole == models.WorkspaceOwner {
+		return nil
+	}
+	if newRole == models.WorkspaceOwner {
+		return models.ErrForbidden
+	}
+	current, err := s.repo.GetMemberRole(memberID, id)
+	if err != nil {
+		if err == models.ErrWorkspaceNotFound {
+			return models.ErrMemberNotFound
+		}
+		return err
+	}
+	if current == models.WorkspaceOwner {
+		return models.ErrForbidden
+	}
+	return nil
+}
+
+// Membership returns the user's role in the workspace, for the middleware
+// that resolves X-Workspace-ID.
+func (s *workspaceService) Membership(userID uuid.UUID, id uuid.UUID) (models.WorkspaceRole, error) {
+	return s.repo.GetMemberRole(userID, id)
+}
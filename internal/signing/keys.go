@@ -0,0 +1,256 @@
+// Package signing holds the keys access tokens are signed and verified
+// with. One key signs new tokens; older keys stay in the set for
+// verification until the tokens they signed have expired, which is how keys
+// are rotated. Public keys are published as a JWKS.
+package signing
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+	AlgHS256 = "HS256"
+)
+
+// Key is one signing or verification key. Private is nil for keys that can
+// only verify.
+type Key struct {
+	ID      string
+	Alg     string
+	Private crypto.Signer
+	Public  crypto.PublicKey
+	secret  []byte
+}
+
+func (k *Key) method() jwt.SigningMethod {
+	switch k.Alg {
+	case AlgRS256:
+		return jwt.SigningMethodRS256
+	case AlgEdDSA:
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodHS256
+}
+
+type KeySet struct {
+	signing *Key
+	byID    map[string]*Key
+}
+
+// LoadFromEnv builds the key set from:
+//
+//	JWT_SIGNING_KEY_FILE        PEM private key (RSA or Ed25519) that signs new tokens
+//	JWT_VERIFICATION_KEY_FILES  comma-separated PEM keys that are still accepted
+//	JWT_SECRET                  legacy HS256 secret, accepted for tokens without a kid
+//
+// With none of them set it generates a throwaway Ed25519 key, so tokens stop
+// working when the process restarts.
+func LoadFromEnv() (*KeySet, error) {
+	ks := &KeySet{byID: make(map[string]*Key)}
+
+	if path := os.Getenv("JWT_SIGNING_KEY_FILE"); path != "" {
+		k, err := loadKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if k.Private == nil {
+			return nil, fmt.Errorf("%s: signing key must be a private key", path)
+		}
+		ks.signing = k
+		ks.byID[k.ID] = k
+	}
+	if v := os.Getenv("JWT_VERIFICATION_KEY_FILES"); v != "" {
+		for _, path := range strings.Split(v, ",") {
+			k, err := loadKeyFile(strings.TrimSpace(path))
+			if err != nil {
+				return nil, err
+			}
+			ks.byID[k.ID] = k
+		}
+	}
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		k := &Key{Alg: AlgHS256, secret: []byte(secret)}
+		ks.byID[""] = k
+		if ks.signing == nil {
+			ks.signing = k
+		}
+	}
+	if ks.signing == nil {
+		log.Println("No JWT signing key configured; using a temporary key. Tokens will not survive a restart.")
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		k, err := newKey(priv)
+		if err != nil {
+			return nil, err
+		}
+		ks.signing = k
+		ks.byID[k.ID] = k
+	}
+	return ks, nil
+}
+
+func loadKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	k, err := newKey(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return k, nil
+}
+
+func newKey(parsed interface{}) (*Key, error) {
+	k := &Key{}
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		k.Alg, k.Private, k.Public = AlgRS256, key, &key.PublicKey
+	case *rsa.PublicKey:
+		k.Alg, k.Public = AlgRS256, key
+	case ed25519.PrivateKey:
+		k.Alg, k.Private, k.Public = AlgEdDSA, key, key.Public()
+	case ed25519.PublicKey:
+		k.Alg, k.Public = AlgEdDSA, key
+	default:
+		return nil, errors.New("key must be RSA or Ed25519")
+	}
+	if rsaKey, ok := k.Public.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < 2048 {
+		return nil, errors.New("RSA keys must be at least 2048 bits")
+	}
+	der, err := x509.MarshalPKIXPublicKey(k.Public)
+	if err != nil {
+		return nil, err
+	}
+	// the kid is derived from the public key, so a key keeps its id when it
+	// moves from signing to verification-only
+	sum := sha256.Sum256(der)
+	k.ID = base64.RawURLEncoding.EncodeToString(sum[:12])
+	return k, nil
+}
+
+// Sign signs claims with the current signing key and sets the kid header.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.method(), claims)
+	if ks.signing.ID != "" {
+		token.Header["kid"] = ks.signing.ID
+	}
+	if ks.signing.secret != nil {
+		return token.SignedString(ks.signing.secret)
+	}
+	return token.SignedString(ks.signing.Private)
+}
+
+// Keyfunc finds the verification key for a token by its kid and checks the
+// token was signed with that key's algorithm.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	k, ok := ks.byID[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != k.Alg {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	if k.secret != nil {
+		return k.secret, nil
+	}
+	return k.Public, nil
+}
+
+// Methods lists the algorithms of all keys, for jwt.WithValidMethods.
+func (ks *KeySet) Methods() []string {
+	seen := map[string]bool{}
+	var methods []string
+	for _, k := range ks.byID {
+		if !seen[k.Alg] {
+			seen[k.Alg] = true
+			methods = append(methods, k.Alg)
+		}
+	}
+	return methods
+}
+
+// JWK is a public key in RFC 7517 form.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public half of every asymmetric key. HS256 secrets are
+// never published.
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	ids := make([]string, 0, len(ks.byID))
+	for id := range ks.byID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		k := ks.byID[id]
+		switch pub := k.Public.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA", Kid: k.ID, Alg: k.Alg, Use: "sig",
+				N: base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP", Kid: k.ID, Alg: k.Alg, Use: "sig",
+				Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return set
+}
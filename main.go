@@ -3,11 +3,19 @@ package main
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/danieldzansi/todo-api/internal/blob"
 	database "github.com/danieldzansi/todo-api/internal/database"
 	"github.com/danieldzansi/todo-api/internal/handlers"
+	"github.com/danieldzansi/todo-api/internal/mailer"
+	models "github.com/danieldzansi/todo-api/internal/model"
+	"github.com/danieldzansi/todo-api/internal/oidc"
 	"github.com/danieldzansi/todo-api/internal/repository"
 	"github.com/danieldzansi/todo-api/internal/services"
+	"github.com/danieldzansi/todo-api/internal/signing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -25,22 +33,141 @@ func main() {
 	}
 	defer conn.Close()
 
+	projectRepo := repository.NewProjectRepository(conn)
+	projectService := services.NewProjectService(projectRepo)
+
+	workspaceRepo := repository.NewWorkspaceRepository(conn)
+
 	todoRepo := repository.NewTodoRepository(conn)
-	todoService := services.NewTodoService(todoRepo)
+	todoService := services.NewTodoService(todoRepo, projectRepo, workspaceRepo)
+
+	retentionDays := 30
+	if v := os.Getenv("TRASH_RETENTION_DAYS"); v != "" {
+		if retentionDays, err = strconv.Atoi(v); err != nil || retentionDays < 1 {
+			log.Fatal("Invalid TRASH_RETENTION_DAYS:", v)
+		}
+	}
+	purgeInterval := time.Hour
+	if v := os.Getenv("TRASH_PURGE_INTERVAL"); v != "" {
+		if purgeInterval, err = time.ParseDuration(v); err != nil || purgeInterval <= 0 {
+			log.Fatal("Invalid TRASH_PURGE_INTERVAL:", v)
+		}
+	}
+	trashPurger := services.NewTrashPurger(todoRepo, time.Duration(retentionDays)*24*time.Hour, purgeInterval)
+	trashPurger.Start()
+	defer trashPurger.Stop()
+
+	tagRepo := repository.NewTagRepository(conn)
+	tagService := services.NewTagService(tagRepo)
 
 	userRepo := repository.NewUserRepository(conn)
-	authService := services.NewAuthService(userRepo)
+	tokenRepo := repository.NewTokenRepository(conn)
+	mail, err := mailer.FromEnv()
+	if err != nil {
+		log.Fatal("Failed to set up mailer:", err)
+	}
+	verifyPolicy, err := services.ParseVerificationPolicy(os.Getenv("REQUIRE_EMAIL_VERIFICATION"))
+	if err != nil {
+		log.Fatal("Invalid REQUIRE_EMAIL_VERIFICATION:", err)
+	}
+	roleRepo := repository.NewRoleRepository(conn)
+	roleService := services.NewRoleService(roleRepo, userRepo)
+	totpRepo := repository.NewTOTPRepository(conn)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(conn)
+	loginRetentionDays := 90
+	if v := os.Getenv("LOGIN_ATTEMPT_RETENTION_DAYS"); v != "" {
+		if loginRetentionDays, err = strconv.Atoi(v); err != nil || loginRetentionDays < 1 {
+			log.Fatal("Invalid LOGIN_ATTEMPT_RETENTION_DAYS:", v)
+		}
+	}
+	loginPurgeInterval := time.Hour
+	if v := os.Getenv("LOGIN_ATTEMPT_PURGE_INTERVAL"); v != "" {
+		if loginPurgeInterval, err = time.ParseDuration(v); err != nil || loginPurgeInterval <= 0 {
+			log.Fatal("Invalid LOGIN_ATTEMPT_PURGE_INTERVAL:", v)
+		}
+	}
+	loginAttemptPurger := services.NewLoginAttemptPurger(loginAttemptRepo, time.Duration(loginRetentionDays)*24*time.Hour, loginPurgeInterval)
+	loginAttemptPurger.Start()
+	defer loginAttemptPurger.Stop()
+	keySet, err := signing.LoadFromEnv()
+	if err != nil {
+		log.Fatal("Failed to load JWT keys:", err)
+	}
+	authService := services.NewAuthService(userRepo, tokenRepo, roleRepo, totpRepo, loginAttemptRepo, keySet, mail, verifyPolicy)
+	oidcConfigs, err := oidc.ConfigsFromEnv(services.AppURL())
+	if err != nil {
+		log.Fatal("Invalid OIDC configuration:", err)
+	}
+	var oidcProviders []*oidc.Provider
+	for _, cfg := range oidcConfigs {
+		oidcProviders = append(oidcProviders, oidc.NewProvider(cfg))
+	}
+	oidcService := services.NewOIDCService(oidcProviders, repository.NewOIDCRepository(conn), userRepo, roleRepo, authService)
+	shareService := services.NewShareService(repository.NewShareRepository(conn), todoRepo, projectRepo, userRepo, mail)
+	workspaceService := services.NewWorkspaceService(workspaceRepo, userRepo, mail)
+	commentService := services.NewCommentService(repository.NewCommentRepository(conn), todoRepo, userRepo, mail)
+
+	blobStore, err := blob.FromEnv()
+	if err != nil {
+		log.Fatal("Failed to set up blob store:", err)
+	}
+	var maxAttachmentSize int64 = models.DefaultMaxAttachmentSize
+	if v := os.Getenv("ATTACHMENT_MAX_BYTES"); v != "" {
+		if maxAttachmentSize, err = strconv.ParseInt(v, 10, 64); err != nil || maxAttachmentSize < 1 {
+			log.Fatal("Invalid ATTACHMENT_MAX_BYTES:", v)
+		}
+	}
+	attachmentTypes := models.DefaultAttachmentTypes
+	if v := os.Getenv("ATTACHMENT_TYPES"); v != "" {
+		attachmentTypes = strings.Split(v, ",")
+	}
+	attachmentRepo := repository.NewAttachmentRepository(conn)
+	attachmentService := services.NewAttachmentService(attachmentRepo, todoRepo, blobStore, maxAttachmentSize, attachmentTypes)
+	sweepInterval := 5 * time.Minute
+	if v := os.Getenv("BLOB_SWEEP_INTERVAL"); v != "" {
+		if sweepInterval, err = time.ParseDuration(v); err != nil || sweepInterval <= 0 {
+			log.Fatal("Invalid BLOB_SWEEP_INTERVAL:", v)
+		}
+	}
+	blobSweeper := services.NewBlobSweeper(attachmentRepo, blobStore, sweepInterval)
+	blobSweeper.Start()
+	defer blobSweeper.Stop()
+
+	if v := os.Getenv("ADMIN_EMAILS"); v != "" {
+		if err := roleService.BootstrapAdmins(strings.Split(v, ",")); err != nil {
+			log.Fatal("Failed to grant admin roles:", err)
+		}
+	}
 
 	todoHandler := handlers.NewTodoHandler(todoService)
 	userHandler := handlers.NewUserHandler(authService)
+	tagHandler := handlers.NewTagHandler(tagService)
+	projectHandler := handlers.NewProjectHandler(projectService)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	oidcHandler := handlers.NewOIDCHandler(oidcService)
+	shareHandler := handlers.NewShareHandler(shareService)
+	workspaceHandler := handlers.NewWorkspaceHandler(workspaceService)
+	commentHandler := handlers.NewCommentHandler(commentService)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService)
 
 	gin.SetMode(os.Getenv("GIN_MODE"))
 
 	router := gin.Default()
+	// login throttling keys on the client IP, so only believe forwarding
+	// headers from proxies we were told about
+	var trustedProxies []string
+	if v := os.Getenv("TRUSTED_PROXIES"); v != "" {
+		trustedProxies = strings.Split(v, ",")
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
 
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 
+	router.GET("/.well-known/jwks.json", handlers.JWKS(keySet))
+
 	api := router.Group("/api/v1")
 	{
 
@@ -51,24 +178,147 @@ func main() {
 			})
 		})
 
+		requireAuth := handlers.AuthMiddleware(authService)
+		// todos:read / todos:write also cover tags and projects
+		requireTodoPerms := handlers.RequireReadWrite(models.PermTodosRead, models.PermTodosWrite)
+		// X-Workspace-ID switches todos and projects to a team workspace
+		workspaceContext := handlers.WorkspaceContext(workspaceService)
+
 		todos := api.Group("/todos")
 		{
 			// protect todos with JWT
-			todos.Use(handlers.AuthMiddleware())
+			todos.Use(requireAuth, requireTodoPerms)
+			if verifyPolicy == services.VerifyBeforeWrites {
+				todos.Use(handlers.RequireVerifiedEmail(authService))
+			}
+			todos.Use(workspaceContext)
 			todos.GET("/", todoHandler.GetAllTodos)
+			todos.GET("/search", todoHandler.SearchTodos)
+			todos.GET("/trash", todoHandler.GetTrash)
+			todos.GET("/shared", shareHandler.GetSharedTodos)
+			todos.GET("/assigned", todoHandler.GetAssignedTodos)
+			todos.GET("/workload", todoHandler.GetWorkload)
+			todos.DELETE("/trash", todoHandler.EmptyTrash)
 			todos.GET("/:id", todoHandler.GetTodoByID)
 			todos.POST("/", todoHandler.CreateTodo)
 			todos.PUT("/:id", todoHandler.UpdateTodo)
 			todos.DELETE("/:id", todoHandler.DeleteTodo)
 			todos.PATCH("/:id/complete", todoHandler.ToggleTodoComplete)
+			todos.POST("/:id/move", todoHandler.MoveTodo)
+			todos.GET("/:id/subtasks", todoHandler.GetSubtasks)
+			todos.POST("/:id/subtasks", todoHandler.CreateSubtask)
+			todos.PUT("/:id/subtasks/order", todoHandler.ReorderSubtasks)
+			todos.PUT("/:id/assignee", todoHandler.AssignTodo)
+			todos.DELETE("/:id/assignee", todoHandler.UnassignTodo)
+			todos.GET("/:id/comments", commentHandler.GetComments)
+			todos.POST("/:id/comments", commentHandler.CreateComment)
+			todos.PATCH("/:id/comments/:commentId", commentHandler.UpdateComment)
+			todos.DELETE("/:id/comments/:commentId", commentHandler.DeleteComment)
+			todos.GET("/:id/attachments", attachmentHandler.GetAttachments)
+			todos.POST("/:id/attachments", attachmentHandler.UploadAttachment)
+			todos.GET("/:id/attachments/:attachmentId", attachmentHandler.DownloadAttachment)
+			todos.DELETE("/:id/attachments/:attachmentId", attachmentHandler.DeleteAttachment)
+			todos.POST("/:id/restore", todoHandler.RestoreTodo)
+			todos.DELETE("/:id/purge", todoHandler.PurgeTodo)
+			todos.GET("/:id/shares", shareHandler.GetTodoShares)
+			todos.POST("/:id/shares", shareHandler.ShareTodo)
+			todos.DELETE("/:id/shares/:userId", shareHandler.UnshareTodo)
+		}
+
+		tags := api.Group("/tags")
+		{
+			tags.Use(requireAuth, requireTodoPerms)
+			tags.GET("/", tagHandler.GetAllTags)
+			tags.GET("/:id", tagHandler.GetTagByID)
+			tags.POST("/", tagHandler.CreateTag)
+			tags.PUT("/:id", tagHandler.UpdateTag)
+			tags.DELETE("/:id", tagHandler.DeleteTag)
+		}
+
+		projects := api.Group("/projects")
+		{
+			projects.Use(requireAuth, requireTodoPerms, workspaceContext)
+			projects.GET("/", projectHandler.GetAllProjects)
+			projects.GET("/shared", shareHandler.GetSharedProjects)
+			projects.GET("/:id", projectHandler.GetProjectByID)
+			projects.POST("/", projectHandler.CreateProject)
+			projects.PUT("/:id", projectHandler.UpdateProject)
+			projects.DELETE("/:id", projectHandler.DeleteProject)
+			projects.GET("/:id/shares", shareHandler.GetProjectShares)
+			projects.POST("/:id/shares", shareHandler.ShareProject)
+			projects.DELETE("/:id/shares/:userId", shareHandler.UnshareProject)
+		}
+
+		invitations := api.Group("/invitations")
+		{
+			invitations.Use(requireAuth, requireTodoPerms)
+			invitations.GET("/", shareHandler.GetInvitations)
+			invitations.POST("/:id/accept", shareHandler.AcceptInvitation)
+			invitations.POST("/:id/decline", shareHandler.DeclineInvitation)
+		}
+
+		workspaces := api.Group("/workspaces")
+		{
+			workspaces.Use(requireAuth, requireTodoPerms)
+			workspaces.GET("/", workspaceHandler.GetWorkspaces)
+			workspaces.POST("/", workspaceHandler.CreateWorkspace)
+			workspaces.GET("/:id", workspaceHandler.GetWorkspace)
+			workspaces.PATCH("/:id", workspaceHandler.UpdateWorkspace)
+			workspaces.DELETE("/:id", workspaceHandler.DeleteWorkspace)
+			workspaces.GET("/:id/members", workspaceHandler.GetMembers)
+			workspaces.POST("/:id/members", workspaceHandler.AddMember)
+			workspaces.PATCH("/:id/members/:userId", workspaceHandler.UpdateMember)
+			workspaces.DELETE("/:id/members/:userId", workspaceHandler.RemoveMember)
 		}
 
 		users := api.Group("/users")
 		{
 			users.POST("/signup", userHandler.Signup)
 			users.POST("/login", userHandler.Login)
-			users.GET("/:id", userHandler.GetUserByID)
-			users.GET("/", userHandler.GetAllUsers)
+			users.POST("/login/verify", userHandler.VerifyLogin)
+			users.POST("/refresh", userHandler.Refresh)
+			users.POST("/logout", requireAuth, handlers.RequireSession(), userHandler.Logout)
+			users.POST("/logout/all", requireAuth, handlers.RequireSession(), userHandler.LogoutAll)
+			users.POST("/password/forgot", userHandler.ForgotPassword)
+			users.POST("/password/reset", userHandler.ResetPassword)
+			users.GET("/verify", userHandler.VerifyEmail)
+			users.POST("/verify", userHandler.VerifyEmail)
+			users.POST("/verify/resend", userHandler.ResendVerification)
+			users.GET("/me", requireAuth, userHandler.GetMe)
+			users.PATCH("/me", requireAuth, handlers.RequireSession(), userHandler.UpdateMe)
+			users.DELETE("/me", requireAuth, handlers.RequireSession(), userHandler.DeleteMe)
+			users.POST("/me/password", requireAuth, handlers.RequireSession(), userHandler.ChangePassword)
+			users.POST("/me/email", requireAuth, handlers.RequireSession(), userHandler.ChangeEmail)
+			users.GET("/me/email/confirm", userHandler.ConfirmEmailChange)
+			users.POST("/me/email/confirm", userHandler.ConfirmEmailChange)
+			users.GET("/me/tokens", requireAuth, handlers.RequireSession(), userHandler.GetPersonalTokens)
+			users.POST("/me/tokens", requireAuth, handlers.RequireSession(), userHandler.CreatePersonalToken)
+			users.DELETE("/me/tokens/:id", requireAuth, handlers.RequireSession(), userHandler.RevokePersonalToken)
+			users.POST("/me/2fa/enroll", requireAuth, handlers.RequireSession(), userHandler.EnrollTOTP)
+			users.POST("/me/2fa/confirm", requireAuth, handlers.RequireSession(), userHandler.ConfirmTOTP)
+			users.POST("/me/2fa/recovery-codes", requireAuth, handlers.RequireSession(), userHandler.RegenerateRecoveryCodes)
+			users.DELETE("/me/2fa", requireAuth, handlers.RequireSession(), userHandler.DisableTOTP)
+			users.GET("/:id", requireAuth, handlers.RequirePermission(models.PermUsersRead), userHandler.GetUserByID)
+			users.GET("/", requireAuth, handlers.RequirePermission(models.PermUsersRead), userHandler.GetAllUsers)
+			users.GET("/:id/roles", requireAuth, handlers.RequirePermission(models.PermRolesManage), roleHandler.GetUserRoles)
+			users.PUT("/:id/roles", requireAuth, handlers.RequirePermission(models.PermRolesManage), roleHandler.SetUserRoles)
+		}
+
+		oidcAuth := api.Group("/auth/oidc")
+		{
+			oidcAuth.GET("/", oidcHandler.GetProviders)
+			oidcAuth.GET("/:provider/login", oidcHandler.Login)
+			oidcAuth.GET("/:provider/callback", oidcHandler.Callback)
+		}
+
+		roles := api.Group("/roles")
+		{
+			roles.Use(requireAuth, handlers.RequirePermission(models.PermRolesManage))
+			roles.GET("/", roleHandler.GetAllRoles)
+			roles.GET("/:name", roleHandler.GetRole)
+			roles.POST("/", roleHandler.CreateRole)
+			roles.PUT("/:name", roleHandler.UpdateRole)
+			roles.DELETE("/:name", roleHandler.DeleteRole)
 		}
 	}
 
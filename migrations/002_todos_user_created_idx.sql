@@ -0,0 +1,3 @@
+-- Keyset pagination index for GET /api/v1/todos
+-- Matches ORDER BY created_at DESC, id DESC scoped to a single user
+CREATE INDEX IF NOT EXISTS idx_todos_user_created_id ON todos (user_id, created_at DESC, id DESC);
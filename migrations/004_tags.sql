@@ -0,0 +1,19 @@
+-- Per-user tags and the todo <-> tag join table
+CREATE TABLE IF NOT EXISTS tags (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    uuid        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name       text        NOT NULL,
+    color      text,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS todo_tags (
+    todo_id uuid NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    tag_id  uuid NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (todo_id, tag_id)
+);
+
+-- Tag names are unique per user, ignoring case
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_user_name ON tags (user_id, lower(name));
+CREATE INDEX IF NOT EXISTS idx_todo_tags_tag_id ON todo_tags (tag_id);
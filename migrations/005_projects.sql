@@ -0,0 +1,16 @@
+-- Projects group a user's todos into lists; todos without one live in the inbox
+CREATE TABLE IF NOT EXISTS projects (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    uuid        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name       text        NOT NULL,
+    color      text,
+    archived   boolean     NOT NULL DEFAULT false,
+    sort_order integer     NOT NULL DEFAULT 0,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS project_id uuid REFERENCES projects(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_projects_user_sort ON projects (user_id, sort_order);
+CREATE INDEX IF NOT EXISTS idx_todos_project_id   ON todos (project_id);
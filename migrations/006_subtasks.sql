@@ -0,0 +1,5 @@
+-- Subtasks are todos with a parent; position orders them under it
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS parent_id uuid REFERENCES todos(id) ON DELETE CASCADE;
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS position integer NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_todos_parent_position ON todos (parent_id, position);
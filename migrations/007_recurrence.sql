@@ -0,0 +1,2 @@
+-- Recurring todos store an RFC 5545 RRULE (e.g. FREQ=WEEKLY;BYDAY=MO)
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS recurrence text;
@@ -0,0 +1,5 @@
+-- Priority levels: 0 none, 1 low, 2 medium, 3 high, 4 urgent
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS priority smallint NOT NULL DEFAULT 0
+    CHECK (priority BETWEEN 0 AND 4);
+
+CREATE INDEX IF NOT EXISTS idx_todos_user_priority_due ON todos (user_id, priority DESC, due_date);
@@ -0,0 +1,4 @@
+-- Soft delete: trashed todos keep their row until restored or purged
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS idx_todos_deleted_at ON todos (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,13 @@
+-- Revoked access tokens, by jti. Rows are only needed until the token
+-- would have expired anyway.
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti        uuid PRIMARY KEY,
+    user_id    uuid        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at timestamptz NOT NULL,
+    revoked_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+-- "Log out all sessions": access tokens issued before this are rejected
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tokens_valid_after timestamptz;
@@ -0,0 +1,2 @@
+-- Set once the user follows the link mailed to them on signup
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at timestamptz;
@@ -0,0 +1,28 @@
+-- Roles grant permissions; users can hold several roles
+CREATE TABLE IF NOT EXISTS roles (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        text        NOT NULL UNIQUE,
+    description text,
+    permissions text[]      NOT NULL DEFAULT '{}',
+    builtin     boolean     NOT NULL DEFAULT false,
+    created_at  timestamptz NOT NULL DEFAULT now(),
+    updated_at  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id uuid NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_roles_role_id ON user_roles (role_id);
+
+INSERT INTO roles (name, description, permissions, builtin) VALUES
+    ('user', 'Manage your own todos', '{todos:read,todos:write}', true),
+    ('admin', 'Manage users and roles', '{todos:read,todos:write,users:read,roles:manage}', true)
+ON CONFLICT (name) DO NOTHING;
+
+-- existing accounts become regular users
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id FROM users u, roles r WHERE r.name = 'user'
+ON CONFLICT DO NOTHING;
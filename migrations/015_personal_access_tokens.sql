@@ -0,0 +1,17 @@
+-- Long-lived, scoped tokens for scripts. Only the SHA-256 hash is stored;
+-- prefix is kept so users can tell their tokens apart.
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+    id           uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id      uuid        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name         text        NOT NULL,
+    token_hash   text        NOT NULL UNIQUE,
+    prefix       text        NOT NULL,
+    scopes       text[]      NOT NULL DEFAULT '{}',
+    expires_at   timestamptz,
+    last_used_at timestamptz,
+    revoked_at   timestamptz,
+    created_at   timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_personal_access_tokens_user_name
+    ON personal_access_tokens (user_id, name) WHERE revoked_at IS NULL;
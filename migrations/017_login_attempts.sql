@@ -0,0 +1,14 @@
+-- Audit trail of login attempts, also used to throttle and lock out
+-- repeated failures per email and per client IP
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    email      text        NOT NULL,
+    user_id    uuid REFERENCES users(id) ON DELETE SET NULL,
+    ip         text        NOT NULL,
+    success    boolean     NOT NULL,
+    reason     text,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_email_created ON login_attempts (email, created_at);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_ip_created    ON login_attempts (ip, created_at);
@@ -0,0 +1,24 @@
+-- In-flight OIDC logins: the state sent to the provider (hashed), the PKCE
+-- verifier and the nonce expected back in the ID token
+CREATE TABLE IF NOT EXISTS oidc_states (
+    state_hash    text PRIMARY KEY,
+    provider      text        NOT NULL,
+    code_verifier text        NOT NULL,
+    nonce         text        NOT NULL,
+    expires_at    timestamptz NOT NULL,
+    created_at    timestamptz NOT NULL DEFAULT now()
+);
+
+-- Accounts at external identity providers linked to users
+CREATE TABLE IF NOT EXISTS user_identities (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    uuid        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider   text        NOT NULL,
+    issuer     text        NOT NULL,
+    subject    text        NOT NULL,
+    email      text,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    UNIQUE (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities (user_id);
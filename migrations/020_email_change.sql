@@ -0,0 +1,2 @@
+-- Email change tokens carry the new address until it is confirmed
+ALTER TABLE user_tokens ADD COLUMN IF NOT EXISTS email text;
@@ -0,0 +1,19 @@
+-- Todos and projects shared with other users. Each row covers either one
+-- todo (and its subtasks) or a whole project, and only counts once the
+-- invitee has accepted it.
+CREATE TABLE IF NOT EXISTS todo_shares (
+    id           uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    todo_id      uuid REFERENCES todos(id) ON DELETE CASCADE,
+    project_id   uuid REFERENCES projects(id) ON DELETE CASCADE,
+    user_id      uuid        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role         text        NOT NULL CHECK (role IN ('viewer', 'editor')),
+    status       text        NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted')),
+    invited_by   uuid REFERENCES users(id) ON DELETE SET NULL,
+    created_at   timestamptz NOT NULL DEFAULT now(),
+    responded_at timestamptz,
+    CHECK ((todo_id IS NULL) <> (project_id IS NULL)),
+    UNIQUE (todo_id, user_id),
+    UNIQUE (project_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_shares_user_status ON todo_shares (user_id, status);
@@ -0,0 +1,26 @@
+-- Team workspaces. Todos and projects with a workspace_id belong to the
+-- workspace and are visible to all of its members; those without one are
+-- the owner's personal todos.
+CREATE TABLE IF NOT EXISTS workspaces (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name       text        NOT NULL,
+    created_by uuid REFERENCES users(id) ON DELETE SET NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id uuid        NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id      uuid        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role         text        NOT NULL CHECK (role IN ('owner', 'admin', 'member')),
+    created_at   timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user_id ON workspace_members (user_id);
+
+ALTER TABLE todos    ADD COLUMN IF NOT EXISTS workspace_id uuid REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS workspace_id uuid REFERENCES workspaces(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_todos_workspace_id    ON todos (workspace_id);
+CREATE INDEX IF NOT EXISTS idx_projects_workspace_id ON projects (workspace_id);
@@ -0,0 +1,5 @@
+-- Todos can be assigned to someone who can see them: a workspace member or
+-- a user the todo is shared with.
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS assignee_id uuid REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_todos_assignee_id ON todos (assignee_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,19 @@
+-- Discussion threads on todos. Comments outlive their author's account;
+-- mentions record which users a comment's @email mentions resolved to.
+CREATE TABLE IF NOT EXISTS todo_comments (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    todo_id    uuid        NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    author_id  uuid REFERENCES users(id) ON DELETE SET NULL,
+    body       text        NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    edited_at  timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_comments_todo_id ON todo_comments (todo_id, created_at, id);
+
+CREATE TABLE IF NOT EXISTS comment_mentions (
+    comment_id uuid NOT NULL REFERENCES todo_comments(id) ON DELETE CASCADE,
+    user_id    uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (comment_id, user_id)
+);
@@ -0,0 +1,38 @@
+-- Files attached to todos. The contents live in the blob store under
+-- storage_key; rows here carry the metadata and the SHA-256 checked on
+-- upload.
+CREATE TABLE IF NOT EXISTS todo_attachments (
+    id           uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    todo_id      uuid        NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    uploaded_by  uuid REFERENCES users(id) ON DELETE SET NULL,
+    filename     text        NOT NULL,
+    content_type text        NOT NULL,
+    size         bigint      NOT NULL CHECK (size > 0),
+    sha256       text        NOT NULL,
+    storage_key  text        NOT NULL UNIQUE,
+    created_at   timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_attachments_todo_id ON todo_attachments (todo_id, created_at);
+
+-- Blobs waiting to be removed from the store. Attachment rows go away in
+-- many ways (deleted directly, or cascading from a purged todo, a deleted
+-- workspace or account), so a trigger queues their blobs here and a
+-- background sweeper deletes them.
+CREATE TABLE IF NOT EXISTS blob_deletions (
+    storage_key text PRIMARY KEY,
+    queued_at   timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE OR REPLACE FUNCTION queue_attachment_blob_deletion() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO blob_deletions (storage_key) VALUES (OLD.storage_key)
+    ON CONFLICT DO NOTHING;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS todo_attachments_queue_blob_deletion ON todo_attachments;
+CREATE TRIGGER todo_attachments_queue_blob_deletion
+    AFTER DELETE ON todo_attachments
+    FOR EACH ROW EXECUTE FUNCTION queue_attachment_blob_deletion();
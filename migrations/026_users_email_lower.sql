@@ -0,0 +1,3 @@
+-- Email lookups ignore case. Not unique, since accounts created before
+-- that may differ only in case.
+CREATE INDEX IF NOT EXISTS idx_users_email_lower ON users (lower(email));